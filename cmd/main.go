@@ -1,20 +1,32 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/articles"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/comments"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/config"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/health"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/lifecycle"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/metrics"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/migrations"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
-	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/utils"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/scheduler"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
 	"github.com/gin-gonic/gin"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
@@ -23,57 +35,220 @@ const articlesUri = currentApiVersionUri + "/articles"
 const commentsUri = articlesUri + "/:id/comments"
 
 func main() {
+	cfg, args, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	logger := initLogging(cfg.Log)
+	if len(args) > 0 {
+		os.Exit(runCommand(cfg, args))
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+	gin.SetMode(cfg.Server.GinMode)
+	if cfg.Auth.EditorToken == "" {
+		slog.Warn("No editor token is set, every request is anonymous and the editor-only endpoints respond with 401")
+	}
+	lc := lifecycle.New()
+	initTracing(cfg.Tracing, lc)
+
 	// Dependency Injection
-	repository := repository.NewRepository(initDb())
-	articleService := articles.NewArticleService(repository)
-	commentService := comments.NewCommentService(repository)
+	repository, checks := initRepository(cfg, lc)
+	articleService := articles.NewArticleService(repository, cfg.Articles.MaxPageSize)
+	commentService := comments.NewCommentService(repository, repository, cfg.Comments.MaxPageSize, cfg.Comments.MaxTreeDepth)
 	handler := handlers.NewRouteHandler(articleService, commentService)
+	healthHandler := handlers.NewHealthHandler(health.NewChecker(checks...))
+	schedulerHandler := handlers.NewSchedulerHandler(initScheduler(cfg.Scheduler, repository, lc))
 
 	// Route Defintions
-	route := gin.Default()
+	route := gin.New()
+	route.Use(gin.Recovery(), tracing.Middleware(), handlers.RequestId(logger), metrics.Middleware())
+	route.Use(handlers.RequestTimeout(cfg.Server.RequestTimeout), handlers.Authenticate(cfg.Auth.EditorToken))
+	route.GET("/healthz", healthHandler.Liveness)
+	route.GET("/readyz", healthHandler.Readiness)
+	route.GET(currentApiVersionUri+"/status", healthHandler.Status)
+	route.GET("/metrics", gin.WrapH(metrics.Handler()))
 	route.GET(articlesUri+"/:id", handler.GetArticleById)
 	route.GET(articlesUri, handler.GetArticles)
-	route.POST(articlesUri, handler.CreateArticle)
+	route.GET(articlesUri+"/search", handler.SearchArticles)
+	route.GET(articlesUri+"/by-slug/:slug", handler.GetArticleBySlug)
+	editors := route.Group(articlesUri, handlers.RequireEditor())
+	editors.POST("", handler.CreateArticle)
+	editors.PUT("/:id", handler.UpdateArticle)
+	editors.PATCH("/:id", handler.PatchArticle)
+	editors.DELETE("/:id", handler.DeleteArticle)
+	editors.POST("/:id/schedule", handler.ScheduleArticle)
+	editors.POST("/:id/publish", handler.PublishArticle)
+	editors.POST("/:id/unpublish", handler.UnpublishArticle)
+	editors.POST("/:id/archive", handler.ArchiveArticle)
+	editors.GET("/:id/revisions", handler.GetArticleRevisions)
+	editors.GET("/:id/revisions/diff", handler.DiffArticleRevisions)
+	editors.GET("/:id/revisions/:revision", handler.GetArticleRevision)
+	editors.POST("/:id/revisions/:revision/restore", handler.RestoreArticleRevision)
+	route.GET(currentApiVersionUri+"/tags", handler.GetTags)
+	route.GET(currentApiVersionUri+"/scheduler", handlers.RequireEditor(), schedulerHandler.State)
+	route.POST(currentApiVersionUri+"/scheduler/run", handlers.RequireEditor(), schedulerHandler.Run)
 	route.POST(commentsUri, handler.CreateComment)
 	route.GET(commentsUri, handler.GetCommentsForArticle)
-	route.Run()
+	route.GET(commentsUri+"/:commentId", handler.GetCommentById)
+
+	// The server is registered last to be the first to shut down
+	server := &http.Server{Addr: cfg.Server.ListenAddress, Handler: route}
+	lc.OnShutdown("http server", server.Shutdown)
+	os.Exit(serve(server, lc, cfg.Server.ShutdownTimeout))
+}
+
+// serve runs the server until it fails or SIGINT/SIGTERM is received, then shuts down all the components
+// within the shutdown timeout, a second signal during the shutdown kills the process right away
+// returns the exit code, it's 1 if the server failed or the shutdown completed with errors
+func serve(server *http.Server, lc *lifecycle.Lifecycle, timeout time.Duration) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening and serving HTTP", "address", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		slog.Error("The server stopped unexpectedly", "error", err)
+		exitCode = 1
+	case <-ctx.Done():
+		slog.Info("Shutdown signal received, draining in-flight requests")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Shutdown completed with errors", "error", err)
+		return 1
+	}
+	slog.Info("Shutdown completed")
+	return exitCode
 }
 
-func initDb() *sql.DB {
-	driverName := os.Getenv("DATABASE_DRIVER") // e.g. postgres
-	username := os.Getenv("DATABASE_USERNAME") // e.g. postgres
-	password := os.Getenv("DATABASE_PASSWORD") // e.g. password
-	host := os.Getenv("DATABASE_HOST")         // e.g. localhost
-	port := os.Getenv("DATABASE_PORT")         // e.g. 5432
-	if utils.ContainsEmpty(driverName, username, password, host, port) {
-		panicMessage := fmt.Sprintf(`Please provide the following environment variables before starting:
-			- DATABASE_DRIVER (e.g. postgres) the provided value was [%s]
-			- DATABASE_USERNAME (e.g. postgres) the provided value was [%s]
-			- DATABASE_PASSWORD (e.g. password) the provided value was [%s]
-			- DATABASE_HOST (e.g. localhost) the provided value was [%s]
-			- DATABASE_PORT (e.g. 5432) the provided value was [%s]`,
-			driverName, username, password, host, port)
-		panic(panicMessage)
-	}
-	uri := driverName + "://" + username + ":" + password + "@" + host + ":" + port + "/articles"
-	database, err := sql.Open("pgx", uri)
+// initLogging logs JSON lines to stdout filtered by the configured levels, e.g. info,repository=debug
+// it's also set as the default logger so any other log line is JSON as well
+func initLogging(cfg config.Log) *slog.Logger {
+	levels, err := logging.ParseLevels(cfg.Level)
 	if err != nil {
-		database.Close()
 		panic(err)
 	}
+	logger := logging.New(os.Stdout, levels)
+	slog.SetDefault(logger)
+	return logger
+}
 
-	applyMigration(database)
-	return database
+// initTracing exports the traces with the configured exporter, the pending spans are flushed on shutdown
+func initTracing(cfg config.Tracing, lc *lifecycle.Lifecycle) {
+	shutdown, err := tracing.Init(context.Background(), cfg.Exporter, cfg.File)
+	if err != nil {
+		panic(err)
+	}
+	lc.OnShutdown("tracer provider", shutdown)
+}
+
+// initRepository picks the configured storage, either postgres (default), sqlite or memory
+// the database pool is closed on shutdown, the returned checks are the ones the readiness depends on
+func initRepository(cfg *config.Config, lc *lifecycle.Lifecycle) (repository.Storage, []health.Check) {
+	if cfg.Storage == config.StorageMemory {
+		slog.Warn("Using in-memory storage, all data will be lost when the server stops")
+		return repository.NewMemoryRepository(), nil
+	}
+	database := openDatabase(cfg)
+	metrics.RegisterDB(database, cfg.Storage)
+	lc.OnShutdown("database pool", func(context.Context) error { return database.Close() })
+	if cfg.Migrations.Auto {
+		if err := applyMigrations(database, cfg); err != nil {
+			panic(fmt.Sprintf("Applying the migrations failed, fix the database then run the migrate force command: %v", err))
+		}
+	} else {
+		slog.Warn("Auto migration is disabled, the server won't be ready until the migrations are applied with the migrate command")
+	}
+	checks := databaseChecks(database, cfg)
+	if cfg.Storage == config.StorageSqlite {
+		return repository.NewSqliteRepository(database), checks
+	}
+	return repository.NewRepository(database), checks
+}
+
+// initScheduler creates the scheduler of the scheduled articles and starts it if it's enabled, it's stopped on shutdown
+// before the database pool it uses
+func initScheduler(cfg config.Scheduler, repository repository.Storage, lc *lifecycle.Lifecycle) *scheduler.Scheduler {
+	articlesScheduler := scheduler.New(repository, cfg.Interval, cfg.BatchSize)
+	if !cfg.Enabled {
+		slog.Warn("The scheduler is disabled, the scheduled articles are only published when it's run manually")
+		return articlesScheduler
+	}
+	articlesScheduler.Start()
+	lc.OnShutdown("scheduler", articlesScheduler.Shutdown)
+	return articlesScheduler
 }
 
-func applyMigration(database *sql.DB) {
-	migrationDriver, err := postgres.WithInstance(database, &postgres.Config{})
+// databaseChecks checks the database connection and that its schema is at the last migration of the storage
+func databaseChecks(database *sql.DB, cfg *config.Config) []health.Check {
+	src, err := migrations.Source(cfg.Storage, cfg.Migrations.Dir)
 	if err != nil {
 		panic(err)
 	}
-	m, err := migrate.NewWithDatabaseInstance("file://db", "articles", migrationDriver)
+	defer src.Close()
+	latestVersion, err := health.LatestMigrationVersion(src)
 	if err != nil {
 		panic(err)
 	}
-	m.Up()
+	return []health.Check{health.DatabaseCheck(database), health.MigrationCheck(database, latestVersion)}
+}
+
+// openDatabase opens the Postgres or SQLite database, a SQLite file is created if needed
+func openDatabase(cfg *config.Config) *sql.DB {
+	driverName, dsn := "pgx", cfg.Database.ConnectionString()
+	if cfg.Storage == config.StorageSqlite {
+		driverName, dsn = "sqlite", repository.SqliteDsn(cfg.Sqlite.Path)
+	}
+	database, err := sql.Open(driverName, dsn)
+	if err != nil {
+		panic(err)
+	}
+	return database
+}
+
+func applyMigrations(database *sql.DB, cfg *config.Config) error {
+	migrator, err := migrations.New(context.Background(), database, cfg.Storage, cfg.Migrations.Dir)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+	return migrator.Up()
+}
+
+// runCommand runs a subcommand instead of the server and returns the exit code, the only one is
+// migrate (up, down N, goto V, version or force V) e.g. go run cmd/main.go -storage sqlite migrate down 1
+func runCommand(cfg *config.Config, args []string) int {
+	if args[0] != "migrate" {
+		fmt.Fprintf(os.Stderr, "Unknown command [%s], the only command is migrate\n", args[0])
+		return 2
+	}
+	if cfg.Storage == config.StorageMemory {
+		fmt.Fprintln(os.Stderr, "The memory storage has no migrations")
+		return 2
+	}
+	database := openDatabase(cfg)
+	defer database.Close()
+	migrator, err := migrations.New(context.Background(), database, cfg.Storage, cfg.Migrations.Dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Connecting to the database failed: %v\n", err)
+		return 1
+	}
+	defer migrator.Close()
+	if err = migrations.Command(migrator, args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Migrating failed: %v\n", err)
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,18 @@
+ALTER TABLE article ADD COLUMN IF NOT EXISTS slug VARCHAR(255);
+
+-- Every slug an article had, the previous ones redirect to its current slug so they're never given to another article
+-- base is the slug made out of the title, slug differs from it when it got a collision suffix
+CREATE TABLE IF NOT EXISTS article_slug (
+    slug VARCHAR(255) PRIMARY KEY,
+    article_id INTEGER NOT NULL REFERENCES article (id) ON DELETE CASCADE,
+    base VARCHAR(255) NOT NULL,
+    creation_timestamp TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS article_slug_article_id_idx ON article_slug (article_id);
+
+-- The existing articles are addressed by their id until they're updated, the slugs are made out of the titles by the service
+UPDATE article SET slug = 'article-' || id;
+INSERT INTO article_slug (slug, article_id, base, creation_timestamp) SELECT slug, id, slug, NOW() FROM article;
+
+CREATE UNIQUE INDEX IF NOT EXISTS article_slug_idx ON article (slug);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS article_search_vector_idx;
+
+ALTER TABLE article DROP COLUMN IF EXISTS search_vector;
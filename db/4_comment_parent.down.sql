@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS comment_parent_id_idx;
+
+ALTER TABLE comment DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS comment_article_id_idx;
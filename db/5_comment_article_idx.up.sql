@@ -0,0 +1,2 @@
+-- Comments are always listed per article ordered by creation_timestamp then id
+CREATE INDEX IF NOT EXISTS comment_article_id_idx ON comment (article_id, creation_timestamp, id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS article_status_idx;
+
+ALTER TABLE article DROP COLUMN IF EXISTS status;
@@ -0,0 +1,4 @@
+ALTER TABLE article ADD COLUMN publish_at TIMESTAMP;
+
+-- The scheduler only looks for the scheduled articles that are due
+CREATE INDEX IF NOT EXISTS article_publish_at_idx ON article (publish_at) WHERE status = 'scheduled';
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS article_revision (
+    article_id INTEGER NOT NULL REFERENCES article (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    title VARCHAR(255),
+    content VARCHAR(65536),
+    tags TEXT,
+    status VARCHAR(16) NOT NULL,
+    creation_timestamp TIMESTAMP NOT NULL,
+    PRIMARY KEY (article_id, revision)
+);
+
+-- The existing articles start their history with their current version
+INSERT INTO article_revision (article_id, revision, title, content, tags, status, creation_timestamp)
+SELECT id, 1, title, content,
+    (SELECT string_agg(tag.name, ',') FROM article_tag JOIN tag ON tag.id = article_tag.tag_id
+        WHERE article_tag.article_id = article.id),
+    status, COALESCE(creation_timestamp, NOW())
+FROM article;
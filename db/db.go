@@ -0,0 +1,9 @@
+// Package db embeds the SQL migrations so the binary doesn't depend on its working directory
+package db
+
+import "embed"
+
+// Migrations holds the Postgres migrations at its root and the SQLite ones under sqlite/
+//
+//go:embed *.sql sqlite/*.sql
+var Migrations embed.FS
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS article_slug_idx;
+
+DROP TABLE IF EXISTS article_slug;
+
+ALTER TABLE article DROP COLUMN slug;
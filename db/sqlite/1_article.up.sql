@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS article (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title VARCHAR(255),
+    content VARCHAR(65536),
+    creation_timestamp TIMESTAMP
+);
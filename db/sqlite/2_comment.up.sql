@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS comment (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER REFERENCES article (id),
+    author VARCHAR(255),
+    content VARCHAR(65536),
+    creation_timestamp TIMESTAMP
+);
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS article_fts_update;
+
+DROP TRIGGER IF EXISTS article_fts_delete;
+
+DROP TRIGGER IF EXISTS article_fts_insert;
+
+DROP TABLE IF EXISTS article_fts;
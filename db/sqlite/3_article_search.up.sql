@@ -0,0 +1,22 @@
+CREATE VIRTUAL TABLE IF NOT EXISTS article_fts USING fts5(
+    title,
+    content,
+    content = 'article',
+    content_rowid = 'id',
+    tokenize = 'porter unicode61'
+);
+
+INSERT INTO article_fts (rowid, title, content) SELECT id, coalesce(title, ''), coalesce(content, '') FROM article;
+
+CREATE TRIGGER IF NOT EXISTS article_fts_insert AFTER INSERT ON article BEGIN
+    INSERT INTO article_fts (rowid, title, content) VALUES (new.id, coalesce(new.title, ''), coalesce(new.content, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_fts_delete AFTER DELETE ON article BEGIN
+    INSERT INTO article_fts (article_fts, rowid, title, content) VALUES ('delete', old.id, coalesce(old.title, ''), coalesce(old.content, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS article_fts_update AFTER UPDATE ON article BEGIN
+    INSERT INTO article_fts (article_fts, rowid, title, content) VALUES ('delete', old.id, coalesce(old.title, ''), coalesce(old.content, ''));
+    INSERT INTO article_fts (rowid, title, content) VALUES (new.id, coalesce(new.title, ''), coalesce(new.content, ''));
+END;
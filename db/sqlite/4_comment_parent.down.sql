@@ -0,0 +1,17 @@
+-- SQLite can't drop a column referencing another table, so the table is rebuilt without it
+DROP INDEX IF EXISTS comment_parent_id_idx;
+
+CREATE TABLE comment_without_parent (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER REFERENCES article (id),
+    author VARCHAR(255),
+    content VARCHAR(65536),
+    creation_timestamp TIMESTAMP
+);
+
+INSERT INTO comment_without_parent (id, article_id, author, content, creation_timestamp)
+    SELECT id, article_id, author, content, creation_timestamp FROM comment;
+
+DROP TABLE comment;
+
+ALTER TABLE comment_without_parent RENAME TO comment;
@@ -0,0 +1,3 @@
+ALTER TABLE comment ADD COLUMN parent_id INTEGER REFERENCES comment (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS comment_parent_id_idx ON comment (parent_id);
@@ -0,0 +1,5 @@
+-- Articles created before the lifecycle existed were public, so they start as published
+ALTER TABLE article ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published'
+    CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+
+CREATE INDEX IF NOT EXISTS article_status_idx ON article (status, creation_timestamp, id);
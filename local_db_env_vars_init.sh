@@ -1,7 +1,8 @@
 #!/usr/bin/env bash
 
-export DATABASE_DRIVER="postgres"
 export DATABASE_USERNAME="postgres"
 export DATABASE_PASSWORD="postgres"
 export DATABASE_HOST="localhost"
-export DATABASE_PORT=5433
\ No newline at end of file
+export DATABASE_PORT=5433
+export DATABASE_NAME="articles"
+export DATABASE_SSL_MODE="disable"
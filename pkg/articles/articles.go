@@ -1,41 +1,408 @@
 package articles
 
 import (
-	"database/sql"
+	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/diff"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/metrics"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/slug"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/validation"
 )
 
 type articleService struct {
-	repo repository.ArticleRepository
+	repo        repository.ArticleRepository
+	maxPageSize int
 }
 
 type ArticleService interface {
-	GetArticleById(id int) (*models.Article, error)
-	GetArticles() ([]models.Article, error)
-	CreateArticle(article *models.Article) error
+	GetArticleById(ctx context.Context, id int) (*models.Article, error)
+	GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error)
+	GetArticles(ctx context.Context, limit int, cursor string, filter models.ArticleFilter) (*models.ArticlePage, error)
+	Search(ctx context.Context, query string, limit int, cursor string) (*models.ArticleSearchPage, error)
+	CreateArticle(ctx context.Context, article *models.Article) error
+	UpdateArticle(ctx context.Context, article *models.Article) error
+	PatchArticle(ctx context.Context, id int, patch *models.ArticlePatch) (*models.Article, error)
+	DeleteArticle(ctx context.Context, id int) error
+	ScheduleArticle(ctx context.Context, id int, schedule *models.ArticleSchedule) (*models.Article, error)
+	PublishArticle(ctx context.Context, id int) (*models.Article, error)
+	UnpublishArticle(ctx context.Context, id int) (*models.Article, error)
+	ArchiveArticle(ctx context.Context, id int) (*models.Article, error)
+	GetTags(ctx context.Context) ([]models.TagCount, error)
+	GetArticleRevisions(ctx context.Context, id int, limit int, cursor string) (*models.ArticleRevisionPage, error)
+	GetArticleRevision(ctx context.Context, id int, revision int) (*models.ArticleRevision, error)
+	DiffArticleRevisions(ctx context.Context, id int, from int, to int) (string, error)
+	RestoreArticleRevision(ctx context.Context, id int, revision int) (*models.Article, error)
 }
 
-func NewArticleService(repo repository.ArticleRepository) ArticleService {
-	return &articleService{repo: repo}
+// NewArticleService creates the article service, maxPageSize caps the page size of listings
+// and falls back to pagination.DefaultMaxPageSize if it's not positive, every call is traced
+func NewArticleService(repo repository.ArticleRepository, maxPageSize int) ArticleService {
+	if maxPageSize <= 0 {
+		maxPageSize = pagination.DefaultMaxPageSize
+	}
+	return &tracedArticleService{next: &articleService{repo: repo, maxPageSize: maxPageSize}}
+}
+
+var (
+	ErrArticleNotFound  = domainerr.NotFound("article_not_found", "no article was found")
+	ErrEmptySearchQuery = domainerr.Validation("search_query_missing", "please provide a search query")
+
+	ErrInvalidStatusTransition = domainerr.Conflict("invalid_status_transition", "the article's status doesn't allow this transition")
+	ErrInvalidPublishAt        = domainerr.Validation("invalid_publish_at", "please provide a publish_at in the future")
+
+	ErrRevisionNotFound = domainerr.NotFound("revision_not_found", "no revision was found")
+)
+
+// diffContext is the number of unchanged lines around every change of a revisions diff
+const diffContext = 3
+
+// transition is a change of an article's status that's only allowed from the from statuses
+type transition struct {
+	name string
+	from []models.ArticleStatus
+	to   models.ArticleStatus
+}
+
+// The article lifecycle, articles are created as drafts and only the published ones are visible to anonymous readers
+// scheduled articles are published by the scheduler once their publish_at is due, scheduling them again changes it
+var (
+	schedule  = transition{name: "schedule", from: []models.ArticleStatus{models.StatusDraft, models.StatusScheduled}, to: models.StatusScheduled}
+	publish   = transition{name: "publish", from: []models.ArticleStatus{models.StatusDraft, models.StatusScheduled}, to: models.StatusPublished}
+	unpublish = transition{name: "unpublish", from: []models.ArticleStatus{models.StatusScheduled, models.StatusPublished, models.StatusArchived}, to: models.StatusDraft}
+	archive   = transition{name: "archive", from: []models.ArticleStatus{models.StatusDraft, models.StatusScheduled, models.StatusPublished}, to: models.StatusArchived}
+)
+
+// GetArticleById returns the article, anonymous readers get ErrArticleNotFound for the articles that aren't published
+func (service *articleService) GetArticleById(ctx context.Context, id int) (*models.Article, error) {
+	article, err := service.repo.GetArticleById(ctx, id)
+	if err == nil && !visible(ctx, article.Status) {
+		err = repository.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, articleError(id, "getting", err)
+	}
+	return article, nil
+}
+
+// GetArticleBySlug returns the article having the slug as its current or one of its previous slugs, the returned article's Slug
+// is its current one. Anonymous readers get ErrArticleNotFound for the articles that aren't published
+func (service *articleService) GetArticleBySlug(ctx context.Context, articleSlug string) (*models.Article, error) {
+	article, err := service.repo.GetArticleBySlug(ctx, articleSlug)
+	if err == nil && !visible(ctx, article.Status) {
+		err = repository.ErrRecordNotFound
+	}
+	if errors.Is(err, domainerr.ErrNotFound) {
+		notFound := ErrArticleNotFound.Wrap(err)
+		notFound.Message = fmt.Sprintf("No article was found for slug: %s", articleSlug)
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting article %q: %w", articleSlug, err)
+	}
+	return article, nil
+}
+
+// GetArticles returns the page of articles matching the filter after the cursor, an empty cursor returns the first page
+// anonymous readers only get the published articles
+func (service *articleService) GetArticles(ctx context.Context, limit int, cursor string, filter models.ArticleFilter) (*models.ArticlePage, error) {
+	after, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = pagination.Limit(limit, service.maxPageSize)
+	if !auth.IsEditor(ctx) {
+		if filter.Status != "" && filter.Status != models.StatusPublished {
+			return &models.ArticlePage{Articles: []models.Article{}}, nil
+		}
+		filter.Status = models.StatusPublished
+	}
+	filter.Tags = normalizeTags(filter.Tags)
+	// Fetching an extra article to know whether there's a next page or not
+	articles, err := service.repo.GetArticles(ctx, limit+1, after, filter)
+	if err != nil {
+		return nil, fmt.Errorf("getting articles: %w", err)
+	}
+	page := &models.ArticlePage{Articles: articles}
+	if len(articles) > limit {
+		page.Articles = articles[:limit]
+		last := page.Articles[limit-1]
+		page.NextCursor = (&pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id}).Encode()
+	}
+	return page, nil
+}
+
+// Search returns the page of articles matching the query ordered by relevance, anonymous readers only get the published articles
+func (service *articleService) Search(ctx context.Context, query string, limit int, cursor string) (*models.ArticleSearchPage, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	offset, err := pagination.DecodeOffset(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = pagination.Limit(limit, service.maxPageSize)
+	results, err := service.repo.SearchArticles(ctx, query, visibleStatus(ctx), limit+1, offset)
+	if err != nil {
+		return nil, fmt.Errorf("searching articles: %w", err)
+	}
+	page := &models.ArticleSearchPage{Results: results}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		page.NextCursor = pagination.EncodeOffset(offset + limit)
+	}
+	return page, nil
+}
+
+// CreateArticle creates the article as a draft without a publish_at, it has to be published to be visible to anonymous readers
+// its slug is made out of its title with a numeric suffix if another article has it
+func (service *articleService) CreateArticle(ctx context.Context, article *models.Article) error {
+	article.Tags = normalizeTags(article.Tags)
+	article.Status, article.PublishAt = models.StatusDraft, nil
+	if err := validation.Validate(article); err != nil {
+		return err
+	}
+	article.Slug = slug.Make(article.Title)
+	if err := service.repo.CreateArticle(ctx, article); err != nil {
+		return fmt.Errorf("creating article: %w", err)
+	}
+	metrics.ArticlesCreated.Inc()
+	logging.For(ctx, "articles").Info("Article created", "article_id", article.Id)
+	return nil
+}
+
+// UpdateArticle replaces the title, content and tags of the article, omitted tags are removed from the article
+// changing the title changes the slug, the previous slug keeps leading to the article
+func (service *articleService) UpdateArticle(ctx context.Context, article *models.Article) error {
+	article.Tags = normalizeTags(article.Tags)
+	if err := validation.Validate(article); err != nil {
+		return err
+	}
+	article.Slug = slug.Make(article.Title)
+	if err := service.repo.UpdateArticle(ctx, article); err != nil {
+		return articleError(article.Id, "updating", err)
+	}
+	logging.For(ctx, "articles").Info("Article updated", "article_id", article.Id)
+	return nil
+}
+
+// PatchArticle applies only the non-nil fields of the patch on the stored article
+func (service *articleService) PatchArticle(ctx context.Context, id int, patch *models.ArticlePatch) (*models.Article, error) {
+	article, err := service.GetArticleById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Title != nil {
+		article.Title = *patch.Title
+	}
+	if patch.Content != nil {
+		article.Content = *patch.Content
+	}
+	if patch.Tags != nil {
+		article.Tags = *patch.Tags
+	}
+	if err = service.UpdateArticle(ctx, article); err != nil {
+		return nil, err
+	}
+	return article, nil
 }
 
-const NoArticleFoundError = "no article was found"
+// DeleteArticle deletes the article and all the comments on it
+func (service *articleService) DeleteArticle(ctx context.Context, id int) error {
+	if err := service.repo.DeleteArticle(ctx, id); err != nil {
+		return articleError(id, "deleting", err)
+	}
+	logging.For(ctx, "articles").Info("Article deleted", "article_id", id)
+	return nil
+}
+
+// ScheduleArticle queues a draft to be published by the scheduler at the schedule's publish_at which must be in the future,
+// a scheduled article is rescheduled
+func (service *articleService) ScheduleArticle(ctx context.Context, id int, request *models.ArticleSchedule) (*models.Article, error) {
+	if request.PublishAt == nil || !request.PublishAt.After(time.Now()) {
+		return nil, ErrInvalidPublishAt
+	}
+	// Stored in UTC with the database precision so the response matches what's stored
+	publishAt := request.PublishAt.UTC().Truncate(time.Microsecond)
+	return service.transition(ctx, id, schedule, &publishAt)
+}
+
+// PublishArticle makes a draft or scheduled article visible to anonymous readers
+func (service *articleService) PublishArticle(ctx context.Context, id int) (*models.Article, error) {
+	return service.transition(ctx, id, publish, nil)
+}
+
+// UnpublishArticle takes any article back to draft, hiding it from anonymous readers
+func (service *articleService) UnpublishArticle(ctx context.Context, id int) (*models.Article, error) {
+	return service.transition(ctx, id, unpublish, nil)
+}
+
+// ArchiveArticle hides the article from anonymous readers without deleting it
+func (service *articleService) ArchiveArticle(ctx context.Context, id int) (*models.Article, error) {
+	return service.transition(ctx, id, archive, nil)
+}
+
+// transition applies the transition on the article if its current status allows it, otherwise ErrInvalidStatusTransition
+// is returned, it's also returned if the status was changed concurrently between reading and updating it
+// publishAt is only set for scheduled articles, it's cleared by any other transition
+func (service *articleService) transition(ctx context.Context, id int, t transition, publishAt *time.Time) (*models.Article, error) {
+	article, err := service.GetArticleById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(t.from, article.Status) {
+		invalid := *ErrInvalidStatusTransition
+		invalid.Message = fmt.Sprintf("Can't %s an article that is %s", t.name, article.Status)
+		return nil, &invalid
+	}
+	err = service.repo.SetArticleStatus(ctx, id, article.Status, t.to, publishAt)
+	if errors.Is(err, domainerr.ErrNotFound) {
+		changed := ErrInvalidStatusTransition.Wrap(err)
+		changed.Message = fmt.Sprintf("The article %d was changed by another request, please retry", id)
+		return nil, changed
+	}
+	if err != nil {
+		return nil, articleError(id, "changing the status of", err)
+	}
+	logging.For(ctx, "articles").Info("Article status changed", "article_id", id, "from", article.Status, "to", t.to)
+	article.Status, article.PublishAt = t.to, publishAt
+	return article, nil
+}
+
+// GetTags returns the tags used by at least one article with their number of articles, the most used first
+// anonymous readers only get the tags of the published articles
+func (service *articleService) GetTags(ctx context.Context) ([]models.TagCount, error) {
+	tags, err := service.repo.GetTags(ctx, visibleStatus(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %w", err)
+	}
+	return tags, nil
+}
+
+// GetArticleRevisions returns the page of the article's revisions after the cursor, the latest first,
+// an empty cursor returns the first page
+func (service *articleService) GetArticleRevisions(ctx context.Context, id int, limit int, cursor string) (*models.ArticleRevisionPage, error) {
+	after, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = service.GetArticleById(ctx, id); err != nil {
+		return nil, err
+	}
+	limit = pagination.Limit(limit, service.maxPageSize)
+	before := 0
+	if after != nil {
+		before = after.Id
+	}
+	// Fetching an extra revision to know whether there's a next page or not
+	revisions, err := service.repo.GetArticleRevisions(ctx, id, limit+1, before)
+	if err != nil {
+		return nil, articleError(id, "getting the revisions of", err)
+	}
+	page := &models.ArticleRevisionPage{Revisions: revisions}
+	if len(revisions) > limit {
+		page.Revisions = revisions[:limit]
+		last := page.Revisions[limit-1]
+		page.NextCursor = (&pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Revision}).Encode()
+	}
+	return page, nil
+}
+
+// GetArticleRevision returns ErrRevisionNotFound if the article doesn't have the revision
+func (service *articleService) GetArticleRevision(ctx context.Context, id int, revision int) (*models.ArticleRevision, error) {
+	if _, err := service.GetArticleById(ctx, id); err != nil {
+		return nil, err
+	}
+	result, err := service.repo.GetArticleRevision(ctx, id, revision)
+	if errors.Is(err, domainerr.ErrNotFound) {
+		notFound := ErrRevisionNotFound.Wrap(err)
+		notFound.Message = fmt.Sprintf("No revision %d was found for article %d", revision, id)
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, articleError(id, fmt.Sprintf("getting revision %d of", revision), err)
+	}
+	return result, nil
+}
 
-func (service *articleService) GetArticleById(id int) (*models.Article, error) {
-	article, err := service.repo.GetArticleById(id)
-	if err != nil && err == sql.ErrNoRows {
-		err = errors.New(NoArticleFoundError)
+// DiffArticleRevisions returns the unified diff from the revision from to the revision to, see revisionText
+// it's empty if nothing changed between them
+func (service *articleService) DiffArticleRevisions(ctx context.Context, id int, from int, to int) (string, error) {
+	fromRevision, err := service.GetArticleRevision(ctx, id, from)
+	if err != nil {
+		return "", err
 	}
-	return article, err
+	toRevision, err := service.GetArticleRevision(ctx, id, to)
+	if err != nil {
+		return "", err
+	}
+	return diff.Unified(fmt.Sprintf("revisions/%d", from), fmt.Sprintf("revisions/%d", to),
+		revisionText(fromRevision), revisionText(toRevision), diffContext), nil
 }
 
-func (service *articleService) GetArticles() ([]models.Article, error) {
-	return service.repo.GetArticles()
+// revisionText is the text of the revision that's diffed, its title, tags and status as header lines
+// then a blank line and its content
+func revisionText(revision *models.ArticleRevision) string {
+	// The contents are trimmed so they don't end with a new line, adding one keeps the last line from being reported as missing it
+	return fmt.Sprintf("Title: %s\nTags: %s\nStatus: %s\n\n%s\n", revision.Title, strings.Join(revision.Tags, ", "), revision.Status, revision.Content)
 }
 
-func (service *articleService) CreateArticle(article *models.Article) error {
-	return service.repo.CreateArticle(article)
+// RestoreArticleRevision updates the article back to the title, content and tags of the revision, which records a new revision,
+// the status is kept as is
+func (service *articleService) RestoreArticleRevision(ctx context.Context, id int, revision int) (*models.Article, error) {
+	restored, err := service.GetArticleRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+	article := &models.Article{Id: id, Title: restored.Title, Content: restored.Content, Tags: restored.Tags}
+	if err = service.UpdateArticle(ctx, article); err != nil {
+		return nil, err
+	}
+	logging.For(ctx, "articles").Info("Article revision restored", "article_id", id, "revision", revision)
+	return article, nil
+}
+
+// visible returns whether an article with the status can be seen by the caller, editors can see all the articles
+func visible(ctx context.Context, status models.ArticleStatus) bool {
+	return status == models.StatusPublished || auth.IsEditor(ctx)
+}
+
+// visibleStatus returns the status the caller's queries are restricted to, an empty status for editors as they see all the articles
+func visibleStatus(ctx context.Context) models.ArticleStatus {
+	if auth.IsEditor(ctx) {
+		return ""
+	}
+	return models.StatusPublished
+}
+
+// normalizeTags trims, lowercases and sorts the tags and removes the duplicates, a nil slice is returned as an empty one
+// empty tags are kept for the validation to report them
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, strings.ToLower(strings.TrimSpace(tag)))
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// articleError maps a missing record to ErrArticleNotFound and wraps any other error with the failed action
+func articleError(id int, action string, err error) error {
+	if errors.Is(err, domainerr.ErrNotFound) {
+		notFound := ErrArticleNotFound.Wrap(err)
+		notFound.Message = fmt.Sprintf("No article was found for id: %d", id)
+		return notFound
+	}
+	return fmt.Errorf("%s article %d: %w", action, id, err)
 }
@@ -0,0 +1,320 @@
+package articles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var editor = auth.WithEditor(context.Background())
+
+func TestCreateArticleShouldCreateDrafts(t *testing.T) {
+	// Given
+	service := NewArticleService(repository.NewMemoryRepository(), 0)
+	publishAt := time.Now().Add(time.Hour)
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusScheduled, PublishAt: &publishAt}
+
+	// When
+	require.NoError(t, service.CreateArticle(editor, article))
+
+	// Then
+	assert.Equal(t, models.StatusDraft, article.Status, "The provided status must be ignored")
+	assert.Nil(t, article.PublishAt, "The provided publish_at must be ignored")
+}
+
+func TestArticleTransitions(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		from     models.ArticleStatus
+		apply    func(service ArticleService, id int) (*models.Article, error)
+		expected models.ArticleStatus
+	}{
+		{"Publish a draft", models.StatusDraft, publishArticle, models.StatusPublished},
+		{"Publish a scheduled article", models.StatusScheduled, publishArticle, models.StatusPublished},
+		{"Unpublish a published article", models.StatusPublished, unpublishArticle, models.StatusDraft},
+		{"Unpublish an archived article", models.StatusArchived, unpublishArticle, models.StatusDraft},
+		{"Archive a draft", models.StatusDraft, archiveArticle, models.StatusArchived},
+		{"Archive a published article", models.StatusPublished, archiveArticle, models.StatusArchived},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Given
+			repo := repository.NewMemoryRepository()
+			service := NewArticleService(repo, 0)
+			id := createArticle(t, repo, test.from)
+
+			// When
+			article, err := test.apply(service, id)
+
+			// Then
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, article.Status)
+			stored, err := repo.GetArticleById(context.Background(), id)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, stored.Status)
+		})
+	}
+}
+
+func TestArticleTransitionsShouldRejectInvalidTransitions(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		from    models.ArticleStatus
+		apply   func(service ArticleService, id int) (*models.Article, error)
+		message string
+	}{
+		{"Publish an archived article", models.StatusArchived, publishArticle, "Can't publish an article that is archived"},
+		{"Publish a published article", models.StatusPublished, publishArticle, "Can't publish an article that is published"},
+		{"Unpublish a draft", models.StatusDraft, unpublishArticle, "Can't unpublish an article that is draft"},
+		{"Archive an archived article", models.StatusArchived, archiveArticle, "Can't archive an article that is archived"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Given
+			repo := repository.NewMemoryRepository()
+			service := NewArticleService(repo, 0)
+			id := createArticle(t, repo, test.from)
+
+			// When
+			_, err := test.apply(service, id)
+
+			// Then
+			assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+			assert.EqualError(t, err, test.message)
+			stored, err := repo.GetArticleById(context.Background(), id)
+			require.NoError(t, err)
+			assert.Equal(t, test.from, stored.Status, "The status must not change")
+		})
+	}
+}
+
+func TestScheduleArticle(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewArticleService(repo, 0)
+	id := createArticle(t, repo, models.StatusDraft)
+	publishAt := time.Now().Add(time.Hour)
+
+	// When
+	article, err := service.ScheduleArticle(editor, id, &models.ArticleSchedule{PublishAt: &publishAt})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusScheduled, article.Status)
+	stored, err := repo.GetArticleById(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusScheduled, stored.Status)
+	require.NotNil(t, stored.PublishAt)
+	assert.WithinDuration(t, publishAt, *stored.PublishAt, time.Microsecond)
+
+	// When published before its time
+	article, err = service.PublishArticle(editor, id)
+
+	// Then
+	require.NoError(t, err)
+	assert.Nil(t, article.PublishAt, "publish_at must be cleared once the article isn't scheduled")
+}
+
+func TestScheduleArticleShouldRejectInvalidSchedules(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	for _, test := range []struct {
+		name     string
+		from     models.ArticleStatus
+		schedule *models.ArticleSchedule
+		expected error
+	}{
+		{"Missing publish_at", models.StatusDraft, &models.ArticleSchedule{}, ErrInvalidPublishAt},
+		{"publish_at in the past", models.StatusDraft, &models.ArticleSchedule{PublishAt: &past}, ErrInvalidPublishAt},
+		{"Schedule a published article", models.StatusPublished, &models.ArticleSchedule{PublishAt: &future}, ErrInvalidStatusTransition},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			// Given
+			repo := repository.NewMemoryRepository()
+			service := NewArticleService(repo, 0)
+			id := createArticle(t, repo, test.from)
+
+			// When
+			_, err := service.ScheduleArticle(editor, id, test.schedule)
+
+			// Then
+			assert.ErrorIs(t, err, test.expected)
+			stored, err := repo.GetArticleById(context.Background(), id)
+			require.NoError(t, err)
+			assert.Equal(t, test.from, stored.Status, "The status must not change")
+		})
+	}
+}
+
+func TestArticleRevisions(t *testing.T) {
+	// Given an article with three revisions
+	repo := repository.NewMemoryRepository()
+	service := NewArticleService(repo, 0)
+	article := &models.Article{Title: "Awesome Go", Content: "Go is\nawesome\n", Tags: []string{"go"}}
+	require.NoError(t, service.CreateArticle(editor, article))
+	_, err := service.PatchArticle(editor, article.Id, &models.ArticlePatch{Content: ptr("Go is\nreally awesome\n")})
+	require.NoError(t, err)
+	_, err = service.PublishArticle(editor, article.Id)
+	require.NoError(t, err)
+
+	t.Run("Pagination", func(t *testing.T) {
+		page, err := service.GetArticleRevisions(editor, article.Id, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, []int{3, 2}, revisionNumbers(page.Revisions))
+		require.NotEmpty(t, page.NextCursor)
+		page, err = service.GetArticleRevisions(editor, article.Id, 2, page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1}, revisionNumbers(page.Revisions))
+		assert.Empty(t, page.NextCursor)
+	})
+	t.Run("Missing revision", func(t *testing.T) {
+		_, err := service.GetArticleRevision(editor, article.Id, 4)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+		_, err = service.GetArticleRevisions(editor, 404, 10, "")
+		assert.ErrorIs(t, err, ErrArticleNotFound)
+	})
+	t.Run("Diff", func(t *testing.T) {
+		diff, err := service.DiffArticleRevisions(editor, article.Id, 1, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "--- revisions/1\n+++ revisions/3\n@@ -1,6 +1,6 @@\n Title: Awesome Go\n Tags: go\n-Status: draft\n+Status: published\n \n"+
+			" Go is\n-awesome\n+really awesome\n", diff)
+		diff, err = service.DiffArticleRevisions(editor, article.Id, 2, 2)
+		require.NoError(t, err)
+		assert.Empty(t, diff, "A revision doesn't differ from itself")
+	})
+	t.Run("Restore", func(t *testing.T) {
+		restored, err := service.RestoreArticleRevision(editor, article.Id, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Go is\nawesome", restored.Content)
+		assert.Equal(t, models.StatusPublished, restored.Status, "Restoring must keep the status")
+		latest, err := service.GetArticleRevision(editor, article.Id, 4)
+		require.NoError(t, err, "Restoring must record a new revision")
+		assert.Equal(t, restored.Content, latest.Content)
+	})
+}
+
+func TestArticleSlugs(t *testing.T) {
+	// Given two articles with the same title
+	service := NewArticleService(repository.NewMemoryRepository(), 0)
+	first := &models.Article{Title: "  Crème Brûlée: 10 recipes! ", Content: "Awesome"}
+	require.NoError(t, service.CreateArticle(editor, first))
+	second := &models.Article{Title: "Crème Brûlée: 10 recipes!", Content: "Awesome"}
+	require.NoError(t, service.CreateArticle(editor, second))
+
+	// Then
+	assert.Equal(t, "creme-brulee-10-recipes", first.Slug)
+	assert.Equal(t, "creme-brulee-10-recipes-2", second.Slug)
+
+	// When the first one is renamed
+	renamed, err := service.PatchArticle(editor, first.Id, &models.ArticlePatch{Title: ptr("Crème Brûlée")})
+	require.NoError(t, err)
+
+	// Then its old slug still leads to it
+	assert.Equal(t, "creme-brulee", renamed.Slug)
+	found, err := service.GetArticleBySlug(editor, "creme-brulee-10-recipes")
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, found.Id)
+	assert.Equal(t, "creme-brulee", found.Slug)
+
+	// And drafts are hidden from anonymous readers
+	_, err = service.GetArticleBySlug(context.Background(), "creme-brulee")
+	assert.ErrorIs(t, err, ErrArticleNotFound)
+}
+
+func TestDiffArticleRevisionsShouldIncludeTheTitleAndTags(t *testing.T) {
+	// Given an article whose title and tags changed but not its content
+	service := NewArticleService(repository.NewMemoryRepository(), 0)
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Tags: []string{"go"}}
+	require.NoError(t, service.CreateArticle(editor, article))
+	_, err := service.PatchArticle(editor, article.Id, &models.ArticlePatch{Title: ptr("Awesome Golang"), Tags: &[]string{"go", "news"}})
+	require.NoError(t, err)
+
+	// When
+	diff, err := service.DiffArticleRevisions(editor, article.Id, 1, 2)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "--- revisions/1\n+++ revisions/2\n@@ -1,5 +1,5 @@\n-Title: Awesome Go\n-Tags: go\n+Title: Awesome Golang\n+Tags: go, news\n"+
+		" Status: draft\n \n Awesome\n", diff)
+}
+
+func TestUnpublishedArticlesShouldBeHiddenFromAnonymousReaders(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewArticleService(repo, 0)
+	published := createArticle(t, repo, models.StatusPublished)
+	draft := createArticle(t, repo, models.StatusDraft)
+	anonymous := context.Background()
+
+	t.Run("Get by id", func(t *testing.T) {
+		_, err := service.GetArticleById(anonymous, draft)
+		assert.ErrorIs(t, err, ErrArticleNotFound)
+		_, err = service.GetArticleById(editor, draft)
+		assert.NoError(t, err, "Editors must see the drafts")
+	})
+	t.Run("Listing", func(t *testing.T) {
+		page, err := service.GetArticles(anonymous, 10, "", models.ArticleFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{published}, articleIds(page.Articles))
+		page, err = service.GetArticles(anonymous, 10, "", models.ArticleFilter{Status: models.StatusDraft})
+		require.NoError(t, err)
+		assert.Empty(t, page.Articles, "Anonymous readers must not be able to list the drafts")
+		page, err = service.GetArticles(editor, 10, "", models.ArticleFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{published, draft}, articleIds(page.Articles))
+	})
+	t.Run("Search", func(t *testing.T) {
+		page, err := service.Search(anonymous, "awesome", 10, "")
+		require.NoError(t, err)
+		require.Len(t, page.Results, 1)
+		assert.Equal(t, published, page.Results[0].Id)
+	})
+	t.Run("Tags", func(t *testing.T) {
+		tags, err := service.GetTags(anonymous)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "published", Count: 1}}, tags)
+	})
+}
+
+func publishArticle(service ArticleService, id int) (*models.Article, error) {
+	return service.PublishArticle(editor, id)
+}
+
+func unpublishArticle(service ArticleService, id int) (*models.Article, error) {
+	return service.UnpublishArticle(editor, id)
+}
+
+func archiveArticle(service ArticleService, id int) (*models.Article, error) {
+	return service.ArchiveArticle(editor, id)
+}
+
+// createArticle stores an article in the status directly as the service only creates drafts, it's tagged with its status
+func createArticle(t *testing.T, repo repository.ArticleRepository, status models.ArticleStatus) int {
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Tags: []string{string(status)}, Status: status}
+	require.NoError(t, repo.CreateArticle(context.Background(), article))
+	return article.Id
+}
+
+func articleIds(articles []models.Article) []int {
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	return ids
+}
+
+func revisionNumbers(revisions []models.ArticleRevision) []int {
+	numbers := []int{}
+	for _, revision := range revisions {
+		numbers = append(numbers, revision.Revision)
+	}
+	return numbers
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
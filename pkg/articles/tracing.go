@@ -0,0 +1,119 @@
+package articles
+
+import (
+	"context"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracedArticleService starts a span around every call to the wrapped service
+type tracedArticleService struct {
+	next ArticleService
+}
+
+func (service *tracedArticleService) GetArticleById(ctx context.Context, id int) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetArticleById", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetArticleById(ctx, id)
+}
+
+func (service *tracedArticleService) GetArticleBySlug(ctx context.Context, slug string) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetArticleBySlug", attribute.String("article.slug", slug))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetArticleBySlug(ctx, slug)
+}
+
+func (service *tracedArticleService) GetArticles(ctx context.Context, limit int, cursor string, filter models.ArticleFilter) (page *models.ArticlePage, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetArticles", attribute.Int("page.limit", limit),
+		attribute.StringSlice("article.tags", filter.Tags), attribute.Bool("article.tags.match_all", filter.MatchAllTags),
+		attribute.String("article.status", string(filter.Status)))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetArticles(ctx, limit, cursor, filter)
+}
+
+func (service *tracedArticleService) Search(ctx context.Context, query string, limit int, cursor string) (page *models.ArticleSearchPage, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.Search", attribute.Int("page.limit", limit))
+	defer func() { tracing.End(span, err) }()
+	return service.next.Search(ctx, query, limit, cursor)
+}
+
+func (service *tracedArticleService) CreateArticle(ctx context.Context, article *models.Article) (err error) {
+	ctx, span := tracing.Start(ctx, "articleService.CreateArticle")
+	defer func() { tracing.End(span, err) }()
+	return service.next.CreateArticle(ctx, article)
+}
+
+func (service *tracedArticleService) UpdateArticle(ctx context.Context, article *models.Article) (err error) {
+	ctx, span := tracing.Start(ctx, "articleService.UpdateArticle", attribute.Int("article.id", article.Id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.UpdateArticle(ctx, article)
+}
+
+func (service *tracedArticleService) PatchArticle(ctx context.Context, id int, patch *models.ArticlePatch) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.PatchArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.PatchArticle(ctx, id, patch)
+}
+
+func (service *tracedArticleService) DeleteArticle(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "articleService.DeleteArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.DeleteArticle(ctx, id)
+}
+
+func (service *tracedArticleService) ScheduleArticle(ctx context.Context, id int, schedule *models.ArticleSchedule) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.ScheduleArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.ScheduleArticle(ctx, id, schedule)
+}
+
+func (service *tracedArticleService) PublishArticle(ctx context.Context, id int) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.PublishArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.PublishArticle(ctx, id)
+}
+
+func (service *tracedArticleService) UnpublishArticle(ctx context.Context, id int) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.UnpublishArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.UnpublishArticle(ctx, id)
+}
+
+func (service *tracedArticleService) ArchiveArticle(ctx context.Context, id int) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.ArchiveArticle", attribute.Int("article.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.ArchiveArticle(ctx, id)
+}
+
+func (service *tracedArticleService) GetTags(ctx context.Context) (tags []models.TagCount, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetTags")
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetTags(ctx)
+}
+
+func (service *tracedArticleService) GetArticleRevisions(ctx context.Context, id int, limit int, cursor string) (page *models.ArticleRevisionPage, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetArticleRevisions", attribute.Int("article.id", id), attribute.Int("page.limit", limit))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetArticleRevisions(ctx, id, limit, cursor)
+}
+
+func (service *tracedArticleService) GetArticleRevision(ctx context.Context, id int, revision int) (result *models.ArticleRevision, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.GetArticleRevision", attribute.Int("article.id", id), attribute.Int("article.revision", revision))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetArticleRevision(ctx, id, revision)
+}
+
+func (service *tracedArticleService) DiffArticleRevisions(ctx context.Context, id int, from int, to int) (diff string, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.DiffArticleRevisions", attribute.Int("article.id", id),
+		attribute.Int("article.revision.from", from), attribute.Int("article.revision.to", to))
+	defer func() { tracing.End(span, err) }()
+	return service.next.DiffArticleRevisions(ctx, id, from, to)
+}
+
+func (service *tracedArticleService) RestoreArticleRevision(ctx context.Context, id int, revision int) (article *models.Article, err error) {
+	ctx, span := tracing.Start(ctx, "articleService.RestoreArticleRevision", attribute.Int("article.id", id), attribute.Int("article.revision", revision))
+	defer func() { tracing.End(span, err) }()
+	return service.next.RestoreArticleRevision(ctx, id, revision)
+}
@@ -0,0 +1,22 @@
+package auth
+
+import "context"
+
+/*
+ * Editors are the only ones allowed to write articles and to read the articles that aren't published yet,
+ * anyone else is an anonymous reader. The handlers authenticate the request and mark its context,
+ * the services check the context to hide what anonymous readers shouldn't see
+ */
+
+type contextKey struct{}
+
+// WithEditor returns a copy of ctx marked as coming from an editor
+func WithEditor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, true)
+}
+
+// IsEditor returns whether ctx was marked as coming from an editor
+func IsEditor(ctx context.Context) bool {
+	editor, _ := ctx.Value(contextKey{}).(bool)
+	return editor
+}
@@ -1,38 +1,219 @@
 package comments
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"slices"
 
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/metrics"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/validation"
 )
 
 type commentService struct {
-	repo repository.CommentRepository
+	repo         repository.CommentRepository
+	articles     repository.ArticleRepository
+	maxPageSize  int
+	maxTreeDepth int
 }
 
 type CommentService interface {
-	CreateComment(comment *models.Comment) error
-	GetCommentsByArticleId(articleId int) ([]models.Comment, error)
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetCommentById(ctx context.Context, articleId int, id int) (*models.Comment, error)
+	GetComments(ctx context.Context, articleId int, sort models.CommentSort, limit int, cursor string) (*models.CommentPage, error)
+	GetCommentTreeByArticleId(ctx context.Context, articleId int, depth int, sort models.CommentSort) ([]*models.Comment, error)
 }
 
-func NewCommentService(repo *repository.Repository) CommentService {
-	return &commentService{repo: repo}
+const DefaultMaxTreeDepth = 5
+
+// NewCommentService creates the comment service, articles is used to tell apart articles without comments from missing ones
+// and to hide the comments of the articles that aren't published from anonymous readers
+// maxPageSize caps the page size of the flat listing and falls back to pagination.DefaultMaxPageSize if it's not positive,
+// maxTreeDepth caps how deep comment trees can be nested and falls back to DefaultMaxTreeDepth if it's not positive,
+// every call is traced
+func NewCommentService(repo repository.CommentRepository, articles repository.ArticleRepository, maxPageSize int, maxTreeDepth int) CommentService {
+	if maxPageSize <= 0 {
+		maxPageSize = pagination.DefaultMaxPageSize
+	}
+	if maxTreeDepth <= 0 {
+		maxTreeDepth = DefaultMaxTreeDepth
+	}
+	return &tracedCommentService{next: &commentService{repo: repo, articles: articles, maxPageSize: maxPageSize, maxTreeDepth: maxTreeDepth}}
 }
 
-const NoArticleIdProvidedErrorContent = "please provide a valid ArticleId to add the comment"
+var (
+	ErrArticleNotFound        = domainerr.NotFound("article_not_found", "no article was found")
+	ErrCommentNotFound        = domainerr.NotFound("comment_not_found", "no comment was found")
+	ErrCommentArticleNotFound = domainerr.ForeignKey("comment_article_not_found", "please provide a valid ArticleId to add the comment")
+	ErrInvalidParentComment   = domainerr.ForeignKey("invalid_parent_comment", "the parent comment doesn't exist or belongs to another article")
+)
 
-func (service *commentService) CreateComment(comment *models.Comment) error {
+// CreateComment adds the comment to the article, anonymous readers can only comment on published articles
+func (service *commentService) CreateComment(ctx context.Context, comment *models.Comment) error {
 	if comment.ArticleId == 0 {
-		return errors.New(NoArticleIdProvidedErrorContent)
+		return ErrCommentArticleNotFound
+	}
+	if err := validation.Validate(comment); err != nil {
+		return err
+	}
+	if !auth.IsEditor(ctx) {
+		err := service.checkArticle(ctx, comment.ArticleId)
+		if errors.Is(err, domainerr.ErrNotFound) {
+			return ErrCommentArticleNotFound.Wrap(err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if comment.ParentId != nil {
+		parent, err := service.repo.GetCommentById(ctx, *comment.ParentId)
+		if errors.Is(err, domainerr.ErrNotFound) || (err == nil && parent.ArticleId != comment.ArticleId) {
+			return ErrInvalidParentComment
+		}
+		if err != nil {
+			return fmt.Errorf("getting parent comment %d: %w", *comment.ParentId, err)
+		}
+	}
+	err := service.repo.CreateComment(ctx, comment)
+	if errors.Is(err, domainerr.ErrForeignKey) {
+		return ErrCommentArticleNotFound.Wrap(err) // to avoid exposing the repository's error
+	}
+	if err != nil {
+		return fmt.Errorf("creating comment: %w", err)
+	}
+	metrics.CommentsCreated.Inc()
+	logging.For(ctx, "comments").Info("Comment created", "article_id", comment.ArticleId, "comment_id", comment.Id)
+	return nil
+}
+
+// GetCommentById returns the comment of the article, ErrCommentNotFound is returned if the comment belongs to another article
+func (service *commentService) GetCommentById(ctx context.Context, articleId int, id int) (*models.Comment, error) {
+	if !auth.IsEditor(ctx) {
+		if err := service.checkArticle(ctx, articleId); err != nil {
+			return nil, err
+		}
+	}
+	comment, err := service.repo.GetCommentById(ctx, id)
+	if errors.Is(err, domainerr.ErrNotFound) || (err == nil && comment.ArticleId != articleId) {
+		notFound := ErrCommentNotFound.Wrap(err)
+		notFound.Message = fmt.Sprintf("No comment was found for id: %d", id)
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting comment %d: %w", id, err)
+	}
+	return comment, nil
+}
+
+// GetComments returns the page of the article's comments after the cursor in the requested order,
+// an empty cursor returns the first page and ErrArticleNotFound is returned if the article doesn't exist or isn't visible to the caller
+func (service *commentService) GetComments(ctx context.Context, articleId int, sort models.CommentSort, limit int, cursor string) (*models.CommentPage, error) {
+	after, err := pagination.Decode(cursor)
+	if err != nil {
+		return nil, err
 	}
-	err := service.repo.CreateComment(comment)
-	if err != nil && err.Error() == repository.ArticleIdFKErrorContent {
-		return errors.New(NoArticleIdProvidedErrorContent) // to avoid exposing the repository's error
+	limit = pagination.Limit(limit, service.maxPageSize)
+	// Fetching an extra comment to know whether there's a next page or not
+	comments, err := service.repo.GetCommentsPage(ctx, articleId, sort, limit+1, after)
+	if err != nil {
+		return nil, fmt.Errorf("getting comments of article %d: %w", articleId, err)
 	}
-	return err
+	if len(comments) == 0 || !auth.IsEditor(ctx) {
+		if err = service.checkArticle(ctx, articleId); err != nil {
+			return nil, err
+		}
+	}
+	page := &models.CommentPage{Comments: comments}
+	if len(comments) > limit {
+		page.Comments = comments[:limit]
+		last := page.Comments[limit-1]
+		page.NextCursor = (&pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id}).Encode()
+	}
+	return page, nil
 }
 
-func (service *commentService) GetCommentsByArticleId(articleId int) ([]models.Comment, error) {
-	return service.repo.GetCommentsByArticleId(articleId)
+// GetCommentTreeByArticleId returns the top-level comments of the article with their replies nested under them
+// The tree is at most depth levels deep (capped to the service's max depth, 0 means the max depth),
+// replies that would go deeper are flattened into the deepest level after their ancestor
+// Every level of the tree is ordered by the sort, ErrArticleNotFound is returned if the article doesn't exist or isn't visible to the caller
+func (service *commentService) GetCommentTreeByArticleId(ctx context.Context, articleId int, depth int, sort models.CommentSort) ([]*models.Comment, error) {
+	if depth <= 0 || depth > service.maxTreeDepth {
+		depth = service.maxTreeDepth
+	}
+	comments, err := service.repo.GetCommentsByArticleId(ctx, articleId)
+	if err != nil {
+		return nil, fmt.Errorf("getting comments of article %d: %w", articleId, err)
+	}
+	if len(comments) == 0 || !auth.IsEditor(ctx) {
+		if err = service.checkArticle(ctx, articleId); err != nil {
+			return nil, err
+		}
+	}
+	tree := buildTree(comments, depth)
+	if sort == models.CommentSortNewest {
+		reverseTree(tree)
+	}
+	return tree, nil
+}
+
+// checkArticle returns ErrArticleNotFound if the article doesn't exist or isn't published and the caller isn't an editor,
+// editors only need it when an article has no comments
+func (service *commentService) checkArticle(ctx context.Context, articleId int) error {
+	article, err := service.articles.GetArticleById(ctx, articleId)
+	if err == nil && article.Status != models.StatusPublished && !auth.IsEditor(ctx) {
+		err = repository.ErrRecordNotFound
+	}
+	if errors.Is(err, domainerr.ErrNotFound) {
+		notFound := ErrArticleNotFound.Wrap(err)
+		notFound.Message = fmt.Sprintf("No article was found for id: %d", articleId)
+		return notFound
+	}
+	if err != nil {
+		return fmt.Errorf("getting article %d: %w", articleId, err)
+	}
+	return nil
+}
+
+// buildTree expects the comments to be ordered by creation so parents always come before their replies
+func buildTree(comments []models.Comment, maxDepth int) []*models.Comment {
+	roots := []*models.Comment{}
+	nodes := make(map[int]*models.Comment, len(comments))
+	containers := make(map[int]*models.Comment, len(comments)) // the node each comment is nested under, nil for roots
+	depths := make(map[int]int, len(comments))
+	for i := range comments {
+		node := &comments[i]
+		nodes[node.Id] = node
+		var container *models.Comment
+		depths[node.Id] = 1
+		if node.ParentId != nil {
+			if parent, ok := nodes[*node.ParentId]; ok {
+				if depths[parent.Id] < maxDepth {
+					container, depths[node.Id] = parent, depths[parent.Id]+1
+				} else {
+					container, depths[node.Id] = containers[parent.Id], depths[parent.Id]
+				}
+			}
+		}
+		containers[node.Id] = container
+		if container == nil {
+			roots = append(roots, node)
+		} else {
+			container.Replies = append(container.Replies, node)
+		}
+	}
+	return roots
+}
+
+// reverseTree reverses the order of the comments on every level of the tree
+func reverseTree(comments []*models.Comment) {
+	slices.Reverse(comments)
+	for _, comment := range comments {
+		reverseTree(comment.Replies)
+	}
 }
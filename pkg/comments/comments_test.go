@@ -0,0 +1,159 @@
+package comments
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTree(t *testing.T) {
+	// Given 1 <- 2 <- 3 <- 4 and 5 as another top-level comment
+	comments := []models.Comment{
+		{Id: 1}, {Id: 2, ParentId: intPtr(1)}, {Id: 3, ParentId: intPtr(2)}, {Id: 4, ParentId: intPtr(3)}, {Id: 5},
+	}
+
+	// When
+	tree := buildTree(comments, 5)
+
+	// Then
+	assert.Equal(t, []int{1, 5}, ids(tree))
+	assert.Equal(t, []int{2}, ids(tree[0].Replies))
+	assert.Equal(t, []int{3}, ids(tree[0].Replies[0].Replies))
+	assert.Equal(t, []int{4}, ids(tree[0].Replies[0].Replies[0].Replies))
+}
+
+func TestBuildTreeShouldFlattenRepliesDeeperThanMaxDepth(t *testing.T) {
+	// Given 1 <- 2 <- 3 <- 4
+	comments := []models.Comment{
+		{Id: 1}, {Id: 2, ParentId: intPtr(1)}, {Id: 3, ParentId: intPtr(2)}, {Id: 4, ParentId: intPtr(3)},
+	}
+
+	t.Run("Max depth 2", func(t *testing.T) {
+		tree := buildTree(cloneComments(comments), 2)
+		assert.Equal(t, []int{1}, ids(tree))
+		assert.Equal(t, []int{2, 3, 4}, ids(tree[0].Replies), "Replies deeper than 2 levels must be flattened under the top-level comment")
+	})
+	t.Run("Max depth 1", func(t *testing.T) {
+		tree := buildTree(cloneComments(comments), 1)
+		assert.Equal(t, []int{1, 2, 3, 4}, ids(tree), "A max depth of 1 must return a flat list")
+	})
+}
+
+func TestReverseTree(t *testing.T) {
+	// Given 1 <- (2, 3) and 4 as another top-level comment
+	comments := []models.Comment{{Id: 1}, {Id: 2, ParentId: intPtr(1)}, {Id: 3, ParentId: intPtr(1)}, {Id: 4}}
+	tree := buildTree(comments, 5)
+
+	// When
+	reverseTree(tree)
+
+	// Then
+	assert.Equal(t, []int{4, 1}, ids(tree))
+	assert.Equal(t, []int{3, 2}, ids(tree[1].Replies), "Replies must be reversed as well")
+}
+
+func TestGetCommentsShouldCapThePageSize(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewCommentService(repo, repo, 2, 0)
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(context.Background(), article))
+	for range 3 {
+		require.NoError(t, repo.CreateComment(context.Background(), &models.Comment{ArticleId: article.Id, Author: "Ahmed Ehab", Content: "Awesome"}))
+	}
+
+	// When
+	page, err := service.GetComments(context.Background(), article.Id, models.CommentSortOldest, 10, "")
+
+	// Then
+	require.NoError(t, err)
+	assert.Len(t, page.Comments, 2, "The page size must be capped by the configured max page size")
+	assert.NotEmpty(t, page.NextCursor)
+}
+
+func TestGetCommentsShouldReturnNotFoundForMissingArticle(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewCommentService(repo, repo, 0, 0)
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(context.Background(), article))
+
+	// When
+	_, missingErr := service.GetComments(context.Background(), 404, models.CommentSortOldest, 0, "")
+	_, missingTreeErr := service.GetCommentTreeByArticleId(context.Background(), 404, 0, models.CommentSortOldest)
+	page, err := service.GetComments(context.Background(), article.Id, models.CommentSortOldest, 0, "")
+
+	// Then
+	assert.ErrorIs(t, missingErr, ErrArticleNotFound)
+	assert.ErrorIs(t, missingTreeErr, ErrArticleNotFound)
+	require.NoError(t, err, "An article without comments must not be reported as missing")
+	assert.Empty(t, page.Comments)
+}
+
+func TestGetCommentByIdShouldReturnNotFoundForAnotherArticle(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewCommentService(repo, repo, 0, 0)
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusPublished}
+	other := &models.Article{Title: "Other", Content: "Other", Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(context.Background(), article))
+	require.NoError(t, repo.CreateArticle(context.Background(), other))
+	comment := &models.Comment{ArticleId: article.Id, Author: "Ahmed Ehab", Content: "Awesome"}
+	require.NoError(t, service.CreateComment(context.Background(), comment))
+
+	// When
+	found, err := service.GetCommentById(context.Background(), article.Id, comment.Id)
+	_, otherErr := service.GetCommentById(context.Background(), other.Id, comment.Id)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, comment.Id, found.Id)
+	assert.ErrorIs(t, otherErr, ErrCommentNotFound)
+}
+
+func TestCommentsOfUnpublishedArticlesShouldBeHiddenFromAnonymousReaders(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	service := NewCommentService(repo, repo, 0, 0)
+	editor := auth.WithEditor(context.Background())
+	draft := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusDraft}
+	require.NoError(t, repo.CreateArticle(context.Background(), draft))
+	comment := &models.Comment{ArticleId: draft.Id, Author: "Ahmed Ehab", Content: "Awesome"}
+	require.NoError(t, service.CreateComment(editor, comment), "Editors must be able to comment on drafts")
+
+	// When
+	_, listErr := service.GetComments(context.Background(), draft.Id, models.CommentSortOldest, 0, "")
+	_, treeErr := service.GetCommentTreeByArticleId(context.Background(), draft.Id, 0, models.CommentSortOldest)
+	_, getErr := service.GetCommentById(context.Background(), draft.Id, comment.Id)
+	createErr := service.CreateComment(context.Background(), &models.Comment{ArticleId: draft.Id, Author: "Ahmed Ehab", Content: "Awesome"})
+	page, err := service.GetComments(editor, draft.Id, models.CommentSortOldest, 0, "")
+
+	// Then
+	assert.ErrorIs(t, listErr, ErrArticleNotFound)
+	assert.ErrorIs(t, treeErr, ErrArticleNotFound)
+	assert.ErrorIs(t, getErr, ErrArticleNotFound)
+	assert.ErrorIs(t, createErr, ErrCommentArticleNotFound)
+	require.NoError(t, err)
+	assert.Len(t, page.Comments, 1)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func cloneComments(comments []models.Comment) []models.Comment {
+	return append([]models.Comment{}, comments...)
+}
+
+func ids(comments []*models.Comment) []int {
+	result := []int{}
+	for _, comment := range comments {
+		result = append(result, comment.Id)
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package comments
+
+import (
+	"context"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracedCommentService starts a span around every call to the wrapped service
+type tracedCommentService struct {
+	next CommentService
+}
+
+func (service *tracedCommentService) CreateComment(ctx context.Context, comment *models.Comment) (err error) {
+	ctx, span := tracing.Start(ctx, "commentService.CreateComment", attribute.Int("article.id", comment.ArticleId))
+	defer func() { tracing.End(span, err) }()
+	return service.next.CreateComment(ctx, comment)
+}
+
+func (service *tracedCommentService) GetCommentById(ctx context.Context, articleId int, id int) (comment *models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "commentService.GetCommentById", attribute.Int("article.id", articleId), attribute.Int("comment.id", id))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetCommentById(ctx, articleId, id)
+}
+
+func (service *tracedCommentService) GetComments(ctx context.Context, articleId int, sort models.CommentSort, limit int, cursor string) (page *models.CommentPage, err error) {
+	ctx, span := tracing.Start(ctx, "commentService.GetComments", attribute.Int("article.id", articleId),
+		attribute.String("comments.sort", string(sort)), attribute.Int("page.limit", limit))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetComments(ctx, articleId, sort, limit, cursor)
+}
+
+func (service *tracedCommentService) GetCommentTreeByArticleId(ctx context.Context, articleId int, depth int, sort models.CommentSort) (tree []*models.Comment, err error) {
+	ctx, span := tracing.Start(ctx, "commentService.GetCommentTreeByArticleId", attribute.Int("article.id", articleId),
+		attribute.Int("comments.depth", depth), attribute.String("comments.sort", string(sort)))
+	defer func() { tracing.End(span, err) }()
+	return service.next.GetCommentTreeByArticleId(ctx, articleId, depth, sort)
+}
@@ -0,0 +1,409 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/comments"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/scheduler"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * The configuration is loaded from these sources, each one overriding the previous ones
+ *   1. the defaults
+ *   2. the YAML or TOML file passed with -config or the CONFIG_FILE env var
+ *   3. the env vars
+ *   4. the command line flags
+ * Every setting is declared once in Config.settings with its file key, env var and flag
+ */
+
+type Config struct {
+	Server     Server
+	Storage    string
+	Database   Database
+	Sqlite     Sqlite
+	Migrations Migrations
+	Articles   Articles
+	Comments   Comments
+	Auth       Auth
+	Scheduler  Scheduler
+	Log        Log
+	Tracing    Tracing
+}
+
+type Server struct {
+	ListenAddress   string
+	GinMode         string
+	RequestTimeout  time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Database is the Postgres connection, Dsn takes precedence over all the other fields if it's provided
+type Database struct {
+	Dsn      string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	Name     string
+	SslMode  string
+}
+
+type Sqlite struct {
+	Path string
+}
+
+// Migrations tells whether the pending migrations are applied when the server starts,
+// if not they have to be applied with the migrate command before the server is ready
+// Dir overrides the migrations embedded in the binary with the ones in a directory
+type Migrations struct {
+	Auto bool
+	Dir  string
+}
+
+type Articles struct {
+	MaxPageSize int
+}
+
+type Comments struct {
+	MaxPageSize  int
+	MaxTreeDepth int
+}
+
+// Auth holds the token editors send as a bearer token, if it's empty there are no editors and every request is anonymous
+type Auth struct {
+	EditorToken string
+}
+
+// Scheduler tells whether this replica publishes the due scheduled articles every interval, batches of BatchSize at a time
+// when it's disabled the scheduler can still be run manually
+type Scheduler struct {
+	Enabled   bool
+	Interval  time.Duration
+	BatchSize int
+}
+
+type Log struct {
+	Level string
+}
+
+type Tracing struct {
+	Exporter string
+	File     string
+}
+
+const (
+	StoragePostgres = "postgres"
+	StorageSqlite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+var (
+	storages = []string{StoragePostgres, StorageSqlite, StorageMemory}
+	ginModes = []string{"debug", "release", "test"}
+	sslModes = []string{"", "disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	traces   = []string{"", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOtlpFile}
+)
+
+// Default returns the configuration used for anything that's not provided
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			ListenAddress:   ":8080",
+			GinMode:         "debug",
+			RequestTimeout:  10 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Storage: StoragePostgres,
+		Database: Database{
+			Host:     "localhost",
+			Port:     5432,
+			Username: "postgres",
+			Name:     "articles",
+		},
+		Sqlite:     Sqlite{Path: "articles.db"},
+		Migrations: Migrations{Auto: true},
+		Articles:   Articles{MaxPageSize: pagination.DefaultMaxPageSize},
+		Comments:   Comments{MaxPageSize: pagination.DefaultMaxPageSize, MaxTreeDepth: comments.DefaultMaxTreeDepth},
+		Scheduler:  Scheduler{Enabled: true, Interval: scheduler.DefaultInterval, BatchSize: scheduler.DefaultBatchSize},
+		Log:        Log{Level: "info"},
+		Tracing:    Tracing{Exporter: tracing.ExporterNone, File: tracing.DefaultOtlpFile},
+	}
+}
+
+// setting binds a field of the Config to its file key, env var and flag, a setting without a flag can't be passed on the command line
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	target any // *string, *int, *bool or *time.Duration
+	redact func(string) string
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "server.listen_address", env: "LISTEN_ADDRESS", flag: "listen-address", usage: "the address the server listens on", target: &c.Server.ListenAddress},
+		{key: "server.gin_mode", env: "GIN_MODE", flag: "gin-mode", usage: "gin's mode, either debug, release or test", target: &c.Server.GinMode},
+		{key: "server.request_timeout", env: "REQUEST_TIMEOUT", flag: "request-timeout", usage: "the deadline of every request", target: &c.Server.RequestTimeout},
+		{key: "server.shutdown_timeout", env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "the max duration of the graceful shutdown", target: &c.Server.ShutdownTimeout},
+		{key: "storage", env: "STORAGE", flag: "storage", usage: "the storage, either postgres, sqlite or memory", target: &c.Storage},
+		{key: "database.dsn", env: "DATABASE_DSN", flag: "database-dsn", usage: "the full Postgres connection string, overrides the other database settings", target: &c.Database.Dsn, redact: redactDsn},
+		{key: "database.host", env: "DATABASE_HOST", flag: "database-host", usage: "the Postgres host", target: &c.Database.Host},
+		{key: "database.port", env: "DATABASE_PORT", flag: "database-port", usage: "the Postgres port", target: &c.Database.Port},
+		{key: "database.username", env: "DATABASE_USERNAME", flag: "database-username", usage: "the Postgres user", target: &c.Database.Username},
+		{key: "database.password", env: "DATABASE_PASSWORD", usage: "the Postgres password", target: &c.Database.Password, redact: redactAll},
+		{key: "database.name", env: "DATABASE_NAME", flag: "database-name", usage: "the Postgres database", target: &c.Database.Name},
+		{key: "database.ssl_mode", env: "DATABASE_SSL_MODE", flag: "database-ssl-mode", usage: "the Postgres sslmode, e.g. disable or verify-full", target: &c.Database.SslMode},
+		{key: "sqlite.path", env: "SQLITE_PATH", flag: "sqlite-path", usage: "the SQLite database file", target: &c.Sqlite.Path},
+		{key: "migrations.auto", env: "MIGRATIONS_AUTO", flag: "migrations-auto", usage: "apply the pending migrations on start", target: &c.Migrations.Auto},
+		{key: "migrations.dir", env: "MIGRATIONS_DIR", flag: "migrations-dir", usage: "a directory to read the storage's migrations from instead of the embedded ones", target: &c.Migrations.Dir},
+		{key: "articles.max_page_size", env: "ARTICLES_MAX_PAGE_SIZE", flag: "articles-max-page-size", usage: "the max number of articles in a page", target: &c.Articles.MaxPageSize},
+		{key: "comments.max_page_size", env: "COMMENTS_MAX_PAGE_SIZE", flag: "comments-max-page-size", usage: "the max number of comments in a page", target: &c.Comments.MaxPageSize},
+		{key: "comments.max_tree_depth", env: "COMMENTS_MAX_TREE_DEPTH", flag: "comments-max-tree-depth", usage: "the max depth of comment trees", target: &c.Comments.MaxTreeDepth},
+		{key: "auth.editor_token", env: "EDITOR_TOKEN", usage: "the bearer token of the editors, every request is anonymous if it's empty", target: &c.Auth.EditorToken, redact: redactAll},
+		{key: "scheduler.enabled", env: "SCHEDULER_ENABLED", flag: "scheduler-enabled", usage: "publish the due scheduled articles every interval", target: &c.Scheduler.Enabled},
+		{key: "scheduler.interval", env: "SCHEDULER_INTERVAL", flag: "scheduler-interval", usage: "how often the due scheduled articles are published", target: &c.Scheduler.Interval},
+		{key: "scheduler.batch_size", env: "SCHEDULER_BATCH_SIZE", flag: "scheduler-batch-size", usage: "the max number of articles published at a time", target: &c.Scheduler.BatchSize},
+		{key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "the log levels, e.g. info,repository=debug", target: &c.Log.Level},
+		{key: "tracing.exporter", env: "TRACES_EXPORTER", flag: "traces-exporter", usage: "where the traces go, either none, stdout or otlp-file", target: &c.Tracing.Exporter},
+		{key: "tracing.file", env: "TRACES_FILE", flag: "traces-file", usage: "the file the otlp-file exporter appends to", target: &c.Tracing.File},
+	}
+}
+
+// Load loads the configuration from the file, env vars and args (without the program name) in that order of precedence
+// the args left after the flags are returned, e.g. a subcommand
+// lookupEnv is os.LookupEnv outside of tests, an env var that's set overrides the file even if it's empty
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, []string, error) {
+	config := Default()
+	settings := config.settings()
+
+	flags := flag.NewFlagSet("go-articles-test", flag.ContinueOnError)
+	defaultConfigFile, _ := lookupEnv("CONFIG_FILE")
+	configFile := flags.String("config", defaultConfigFile, "a YAML or TOML configuration file")
+	flagValues := map[string]string{}
+	for _, s := range settings {
+		if s.flag == "" {
+			continue
+		}
+		setFlag := func(value string) error {
+			flagValues[s.flag] = value
+			return nil
+		}
+		if _, isBool := s.target.(*bool); isBool {
+			flags.BoolFunc(s.flag, s.usage+" ("+s.env+")", setFlag)
+		} else {
+			flags.Func(s.flag, s.usage+" ("+s.env+")", setFlag)
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	fileValues := map[string]string{}
+	if *configFile != "" {
+		var err error
+		if fileValues, err = readFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+	for key := range fileValues {
+		if !slices.ContainsFunc(settings, func(s setting) bool { return s.key == key }) {
+			return nil, nil, fmt.Errorf("unknown setting %s in %s", key, *configFile)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := fileValues[s.key]; ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s in %s: %w", s.key, *configFile, err)
+			}
+		}
+		if value, ok := lookupEnv(s.env); ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s env var: %w", s.env, err)
+			}
+		}
+		if value, ok := flagValues[s.flag]; ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid -%s flag: %w", s.flag, err)
+			}
+		}
+	}
+	return config, flags.Args(), config.Validate()
+}
+
+// Validate checks that all the settings have supported values
+func (c *Config) Validate() error {
+	var errs []error
+	oneOf := func(name string, value string, allowed []string) {
+		if !slices.Contains(allowed, value) {
+			errs = append(errs, fmt.Errorf("%s must be one of [%s], the provided value was [%s]", name, strings.Join(allowed, ", "), value))
+		}
+	}
+	notNegative := func(name string, value int64) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	oneOf("storage", c.Storage, storages)
+	oneOf("server.gin_mode", c.Server.GinMode, ginModes)
+	oneOf("database.ssl_mode", c.Database.SslMode, sslModes)
+	oneOf("tracing.exporter", c.Tracing.Exporter, traces)
+	notNegative("server.request_timeout", int64(c.Server.RequestTimeout))
+	notNegative("server.shutdown_timeout", int64(c.Server.ShutdownTimeout))
+	notNegative("articles.max_page_size", int64(c.Articles.MaxPageSize))
+	notNegative("comments.max_page_size", int64(c.Comments.MaxPageSize))
+	notNegative("comments.max_tree_depth", int64(c.Comments.MaxTreeDepth))
+	notNegative("scheduler.interval", int64(c.Scheduler.Interval))
+	notNegative("scheduler.batch_size", int64(c.Scheduler.BatchSize))
+	if _, err := logging.ParseLevels(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	if c.Storage == StoragePostgres && c.Database.Dsn == "" && (c.Database.Host == "" || c.Database.Name == "") {
+		errs = append(errs, errors.New("database.dsn or both database.host and database.name must be provided for the postgres storage"))
+	}
+	return errors.Join(errs...)
+}
+
+// ConnectionString returns the DSN if it's provided or builds a postgres:// URL from the other fields
+func (d Database) ConnectionString() string {
+	if d.Dsn != "" {
+		return d.Dsn
+	}
+	uri := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(d.Username, d.Password),
+		Host:   net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:   "/" + d.Name,
+	}
+	if d.SslMode != "" {
+		uri.RawQuery = url.Values{"sslmode": {d.SslMode}}.Encode()
+	}
+	return uri.String()
+}
+
+// LogValue logs every setting with the secrets redacted, it's the only way the configuration should be dumped
+func (c *Config) LogValue() slog.Value {
+	settings := c.settings()
+	attributes := make([]slog.Attr, 0, len(settings))
+	for _, s := range settings {
+		attributes = append(attributes, slog.String(s.key, s.String()))
+	}
+	return slog.GroupValue(attributes...)
+}
+
+// String returns the value of the setting with its secrets redacted
+func (s setting) String() string {
+	var value string
+	switch target := s.target.(type) {
+	case *string:
+		value = *target
+	case *int:
+		value = strconv.Itoa(*target)
+	case *bool:
+		value = strconv.FormatBool(*target)
+	case *time.Duration:
+		value = target.String()
+	}
+	if s.redact != nil && value != "" {
+		return s.redact(value)
+	}
+	return value
+}
+
+func (s setting) set(value string) error {
+	switch target := s.target.(type) {
+	case *string:
+		*target = value
+	case *int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a number", value)
+		}
+		*target = number
+	case *bool:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a boolean", value)
+		}
+		*target = boolean
+	case *time.Duration:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("[%s] is not a duration like 5s", value)
+		}
+		*target = duration
+	}
+	return nil
+}
+
+// readFile reads a YAML or TOML file, chosen by its extension, and flattens it to dotted keys e.g. database.host
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading the configuration file: %w", err)
+	}
+	document := map[string]any{}
+	switch extension := filepath.Ext(path); extension {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &document)
+	case ".toml":
+		err = toml.Unmarshal(content, &document)
+	default:
+		return nil, fmt.Errorf("the configuration file must be either .yaml, .yml or .toml, the provided one was [%s]", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	values := map[string]string{}
+	flatten("", document, values)
+	return values, nil
+}
+
+// flatten adds the leaves of the document to values under their dotted keys, null leaves like `dsn:` are skipped
+// so they keep the value they'd have without the file
+func flatten(prefix string, document map[string]any, values map[string]string) {
+	for key, value := range document {
+		switch value := value.(type) {
+		case nil:
+		case map[string]any:
+			flatten(prefix+key+".", value, values)
+		default:
+			values[prefix+key] = fmt.Sprint(value)
+		}
+	}
+}
+
+func redactAll(string) string {
+	return "REDACTED"
+}
+
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|[^\s&]+)`)
+
+// redactDsn hides the password of either a URL or a key=value DSN
+func redactDsn(dsn string) string {
+	if uri, err := url.Parse(dsn); err == nil && uri.User != nil {
+		if _, hasPassword := uri.User.Password(); hasPassword {
+			uri.User = url.UserPassword(uri.User.Username(), "REDACTED")
+		}
+		dsn = uri.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}REDACTED")
+}
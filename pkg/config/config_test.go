@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDefaults(t *testing.T) {
+	config, _, err := Load(nil, env(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, Default(), config)
+	assert.Equal(t, "postgres://postgres:@localhost:5432/articles", config.Database.ConnectionString())
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	// Given
+	file := writeFile(t, "config.yaml", `
+server:
+  listen_address: ":9000"
+  request_timeout: 3s
+database:
+  host: file-host
+  name: file-db
+  port: 6543
+`)
+	variables := env(map[string]string{"CONFIG_FILE": file, "DATABASE_HOST": "env-host", "LISTEN_ADDRESS": ":9001"})
+
+	// When
+	config, _, err := Load([]string{"-listen-address", ":9002"}, variables)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, ":9002", config.Server.ListenAddress, "Flags must override env vars and the file")
+	assert.Equal(t, "env-host", config.Database.Host, "Env vars must override the file")
+	assert.Equal(t, "file-db", config.Database.Name, "The file must override the defaults")
+	assert.Equal(t, 6543, config.Database.Port)
+	assert.Equal(t, 3*time.Second, config.Server.RequestTimeout)
+	assert.Equal(t, 15*time.Second, config.Server.ShutdownTimeout, "Missing settings must keep their defaults")
+}
+
+func TestLoadShouldLetEmptyEnvVarsOverrideTheFile(t *testing.T) {
+	// Given
+	file := writeFile(t, "config.yaml", "database:\n  ssl_mode: require\n")
+
+	// When
+	config, _, err := Load([]string{"-config", file}, env(map[string]string{"DATABASE_SSL_MODE": ""}))
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, config.Database.SslMode, "An env var that's set must override the file even if it's empty")
+}
+
+func TestLoadShouldSkipNullsInTheFile(t *testing.T) {
+	// Given
+	file := writeFile(t, "config.yaml", "database:\n  dsn:\n  host: file-host\n")
+
+	// When
+	config, _, err := Load([]string{"-config", file}, env(nil))
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, config.Database.Dsn, "A null must keep the default")
+	assert.Equal(t, "file-host", config.Database.Host)
+}
+
+func TestLoadToml(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+storage = "sqlite"
+
+[sqlite]
+path = "/tmp/articles.db"
+
+[articles]
+max_page_size = 50
+`)
+
+	config, _, err := Load([]string{"-config", file}, env(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, StorageSqlite, config.Storage)
+	assert.Equal(t, "/tmp/articles.db", config.Sqlite.Path)
+	assert.Equal(t, 50, config.Articles.MaxPageSize)
+}
+
+func TestLoadBooleansAndArgs(t *testing.T) {
+	t.Run("Bare flag", func(t *testing.T) {
+		config, args, err := Load([]string{"-migrations-auto", "migrate", "up"}, env(map[string]string{"MIGRATIONS_AUTO": "false"}))
+		require.NoError(t, err)
+		assert.True(t, config.Migrations.Auto, "A bare boolean flag must set it to true")
+		assert.Equal(t, []string{"migrate", "up"}, args, "The args after the flags must be returned")
+	})
+	t.Run("Flag with a value", func(t *testing.T) {
+		config, _, err := Load([]string{"-migrations-auto=false"}, env(nil))
+		require.NoError(t, err)
+		assert.False(t, config.Migrations.Auto)
+	})
+	t.Run("Invalid env var", func(t *testing.T) {
+		_, _, err := Load(nil, env(map[string]string{"MIGRATIONS_AUTO": "maybe"}))
+		assert.ErrorContains(t, err, "MIGRATIONS_AUTO")
+	})
+}
+
+func TestLoadShouldFail(t *testing.T) {
+	t.Run("Unknown file setting", func(t *testing.T) {
+		_, _, err := Load([]string{"-config", writeFile(t, "config.yaml", "databse:\n  host: x\n")}, env(nil))
+		assert.ErrorContains(t, err, "unknown setting databse.host")
+	})
+	t.Run("Invalid number", func(t *testing.T) {
+		_, _, err := Load(nil, env(map[string]string{"DATABASE_PORT": "abc"}))
+		assert.ErrorContains(t, err, "DATABASE_PORT")
+	})
+	t.Run("Invalid duration", func(t *testing.T) {
+		_, _, err := Load([]string{"-request-timeout", "10"}, env(nil))
+		assert.ErrorContains(t, err, "request-timeout")
+	})
+	t.Run("Unsupported values", func(t *testing.T) {
+		_, _, err := Load(nil, env(map[string]string{"STORAGE": "mongo", "GIN_MODE": "fast", "LOG_LEVEL": "loud"}))
+		assert.ErrorContains(t, err, "storage")
+		assert.ErrorContains(t, err, "server.gin_mode")
+		assert.ErrorContains(t, err, "log.level")
+	})
+	t.Run("Password flag", func(t *testing.T) {
+		_, _, err := Load([]string{"-database-password", "secret"}, env(nil))
+		assert.Error(t, err, "The password must not be accepted on the command line where it's visible to other users")
+	})
+}
+
+func TestConnectionString(t *testing.T) {
+	t.Run("Built from the fields", func(t *testing.T) {
+		database := Database{Host: "db", Port: 5433, Username: "user", Password: "p@ss word", Name: "news", SslMode: "verify-full"}
+		assert.Equal(t, "postgres://user:p%40ss%20word@db:5433/news?sslmode=verify-full", database.ConnectionString())
+	})
+	t.Run("DSN takes precedence", func(t *testing.T) {
+		database := Database{Dsn: "host=db user=user", Host: "ignored"}
+		assert.Equal(t, "host=db user=user", database.ConnectionString())
+	})
+}
+
+func TestLogValueRedactsSecrets(t *testing.T) {
+	// Given
+	config := Default()
+	config.Database.Password = "hunter2"
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+
+	t.Run("Password", func(t *testing.T) {
+		defer out.Reset()
+		logger.Info("Configuration loaded", "config", config)
+		assert.NotContains(t, out.String(), "hunter2")
+		assert.Contains(t, out.String(), `"database.password":"REDACTED"`)
+	})
+	t.Run("Editor token", func(t *testing.T) {
+		defer out.Reset()
+		config.Auth.EditorToken = "s3cr3t-token"
+		logger.Info("Configuration loaded", "config", config)
+		assert.NotContains(t, out.String(), "s3cr3t-token")
+		assert.Contains(t, out.String(), `"auth.editor_token":"REDACTED"`)
+	})
+	t.Run("URL DSN", func(t *testing.T) {
+		defer out.Reset()
+		config.Database.Dsn = "postgres://user:hunter2@db:5432/articles?sslmode=disable"
+		logger.Info("Configuration loaded", "config", config)
+		assert.NotContains(t, out.String(), "hunter2")
+		assert.Contains(t, out.String(), "postgres://user:REDACTED@db:5432/articles?sslmode=disable")
+	})
+	t.Run("Key value DSN", func(t *testing.T) {
+		defer out.Reset()
+		config.Database.Dsn = "host=db user=user password=hunter2 dbname=articles"
+		logger.Info("Configuration loaded", "config", config)
+		assert.NotContains(t, out.String(), "hunter2")
+		assert.Contains(t, out.String(), "password=REDACTED dbname=articles")
+	})
+}
+
+func env(variables map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
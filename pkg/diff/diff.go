@@ -0,0 +1,168 @@
+// Package diff computes line based unified diffs like diff -u
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxCells bounds the size of the LCS table, larger changes are diffed as removing all the changed lines and adding the new ones
+const maxCells = 1 << 20
+
+// op is a line of the diff, kind is ' ' for a kept line, '-' for a removed one and '+' for an added one
+// a and b are the number of lines of from and to before it
+type op struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// Unified returns the unified diff turning from into to with up to context unchanged lines around every change,
+// fromName and toName label the two sides, an empty string is returned if from and to are equal
+func Unified(fromName, toName, from, to string, context int) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+	var out strings.Builder
+	for i, hunk := range hunks(ops, max(context, 0)) {
+		if i == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		writeHunk(&out, ops[hunk[0]:hunk[1]])
+	}
+	return out.String()
+}
+
+// splitLines splits the text after every new line, the last line has no new line if the text doesn't end with one
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the ops turning a into b, the common prefix and suffix are kept as is
+// and only the lines between them go through editScript
+func diffLines(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]op, 0, len(a)+len(b)-prefix-suffix)
+	ai, bi := 0, 0
+	emit := func(kind byte) {
+		switch kind {
+		case ' ':
+			ops = append(ops, op{kind, a[ai], ai, bi})
+			ai, bi = ai+1, bi+1
+		case '-':
+			ops = append(ops, op{kind, a[ai], ai, bi})
+			ai++
+		case '+':
+			ops = append(ops, op{kind, b[bi], ai, bi})
+			bi++
+		}
+	}
+	for range prefix {
+		emit(' ')
+	}
+	for _, kind := range editScript(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		emit(kind)
+	}
+	for range suffix {
+		emit(' ')
+	}
+	return ops
+}
+
+// editScript returns the kinds of the ops turning a into b keeping their longest common subsequence,
+// the removals come before the additions when both are possible like diff -u
+func editScript(a, b []string) []byte {
+	n, m := len(a), len(b)
+	script := make([]byte, 0, n+m)
+	if n*m > maxCells {
+		return append(append(script, bytes.Repeat([]byte{'-'}, n)...), bytes.Repeat([]byte{'+'}, m)...)
+	}
+	// lcs[i*(m+1)+j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+	for i, j := 0, 0; i < n || j < m; {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			script = append(script, ' ')
+			i, j = i+1, j+1
+		case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			script = append(script, '-')
+			i++
+		default:
+			script = append(script, '+')
+			j++
+		}
+	}
+	return script
+}
+
+// hunks returns the ranges of ops to print, every change with up to context unchanged lines around it
+// changes that are at most 2*context unchanged lines apart share a hunk
+func hunks(ops []op, context int) [][2]int {
+	var result [][2]int
+	for i, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		start, end := max(i-context, 0), min(i+context+1, len(ops))
+		if n := len(result); n > 0 && start <= result[n-1][1] {
+			result[n-1][1] = end
+			continue
+		}
+		result = append(result, [2]int{start, end})
+	}
+	return result
+}
+
+func writeHunk(out *strings.Builder, ops []op) {
+	fromCount, toCount := 0, 0
+	for _, o := range ops {
+		if o.kind != '+' {
+			fromCount++
+		}
+		if o.kind != '-' {
+			toCount++
+		}
+	}
+	fmt.Fprintf(out, "@@ -%s +%s @@\n", hunkRange(ops[0].a, fromCount), hunkRange(ops[0].b, toCount))
+	for _, o := range ops {
+		out.WriteByte(o.kind)
+		out.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats the lines of a hunk that starts after the line number start, an empty range starts at the line before it
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return strconv.Itoa(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
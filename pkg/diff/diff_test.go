@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		from, to string
+		expected string
+	}{
+		{"Equal texts", "a\nb\n", "a\nb\n", ""},
+		{"Changed line", "a\nb\nc\n", "a\nB\nc\n", "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"Added to an empty text", "", "a\n", "--- from\n+++ to\n@@ -0,0 +1 @@\n+a\n"},
+		{"Removed everything", "a\nb\n", "", "--- from\n+++ to\n@@ -1,2 +0,0 @@\n-a\n-b\n"},
+		{"No new line at the end", "a\nb", "a\nc", "--- from\n+++ to\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"},
+		{"New line added at the end", "a", "a\n", "--- from\n+++ to\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Unified("from", "to", test.from, test.to, 3))
+		})
+	}
+}
+
+func TestUnifiedShouldSplitDistantChangesInHunks(t *testing.T) {
+	// Given
+	from := lines(1, 20)
+	to := strings.Replace(strings.Replace(from, "2\n", "two\n", 1), "18\n", "eighteen\n", 1)
+
+	// When
+	diff := Unified("from", "to", from, to, 3)
+
+	// Then
+	assert.Equal(t, "--- from\n+++ to\n"+
+		"@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n"+
+		"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n", diff)
+	assert.Equal(t, 1, strings.Count(Unified("from", "to", from, to, 8), "@@ -"), "Changes closer than twice the context must share a hunk")
+}
+
+func TestUnifiedShouldKeepTheLongestCommonLines(t *testing.T) {
+	diff := Unified("from", "to", "a\nb\nc\nd\n", "b\nc\nd\ne\n", 0)
+	assert.Equal(t, "--- from\n+++ to\n@@ -1 +0,0 @@\n-a\n@@ -4,0 +4 @@\n+e\n", diff)
+}
+
+func lines(from, to int) string {
+	var builder strings.Builder
+	for i := from; i <= to; i++ {
+		builder.WriteString(strconv.Itoa(i) + "\n")
+	}
+	return builder.String()
+}
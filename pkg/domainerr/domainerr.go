@@ -0,0 +1,87 @@
+package domainerr
+
+import "errors"
+
+// The kinds of domain errors, use errors.Is to check an error's kind
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrForeignKey  = errors.New("foreign key violation")
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is a domain error of a specific kind
+// Code is a stable machine-readable identifier of the error and Message is safe to be shown to clients
+// Fields lists the invalid fields for validation errors
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError describes why a single field is invalid, Field is the field's json name
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func New(kind error, code string, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code string, message string) *Error {
+	return New(ErrNotFound, code, message)
+}
+
+func Conflict(code string, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+func Validation(code string, message string) *Error {
+	return New(ErrValidation, code, message)
+}
+
+func ForeignKey(code string, message string) *Error {
+	return New(ErrForeignKey, code, message)
+}
+
+func Unavailable(code string, message string) *Error {
+	return New(ErrUnavailable, code, message)
+}
+
+// WithFields returns a copy of the error with the invalid fields
+func (e *Error) WithFields(fields []FieldError) *Error {
+	withFields := *e
+	withFields.Fields = fields
+	return &withFields
+}
+
+// Wrap returns a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error's kind, or another domain error with the same code
+func (e *Error) Is(target error) bool {
+	if t, ok := target.(*Error); ok {
+		return t.Code == e.Code
+	}
+	return target == e.Kind
+}
@@ -0,0 +1,34 @@
+package domainerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIs(t *testing.T) {
+	// Given
+	errArticleNotFound := NotFound("article_not_found", "no article was found")
+	cause := errors.New("sql: no rows in result set")
+
+	// When
+	err := fmt.Errorf("getting article 1: %w", errArticleNotFound.Wrap(cause))
+
+	// Then
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, errArticleNotFound, "Wrapped copies must match the original error by code")
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, NotFound("comment_not_found", "no comment was found"))
+}
+
+func TestAs(t *testing.T) {
+	err := fmt.Errorf("creating comment: %w", ForeignKey("comment_article_not_found", "no article exists for the comment"))
+
+	var domainErr *Error
+	assert.True(t, errors.As(err, &domainErr))
+	assert.Equal(t, "comment_article_not_found", domainErr.Code)
+	assert.Equal(t, "creating comment: no article exists for the comment", err.Error())
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate marks the requests bearing the editor token in their Authorization header as coming from an editor,
+// requests without the header are anonymous and the ones with a wrong token are responded to with 401
+// an empty editor token disables the editors, every request is anonymous and any token is wrong
+func Authenticate(editorToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+		token, isBearer := strings.CutPrefix(header, "Bearer ")
+		if !isBearer || editorToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(editorToken)) != 1 {
+			logger(c).Info("Invalid credentials were provided")
+			respondUnauthorized(c)
+			return
+		}
+		c.Request = c.Request.WithContext(auth.WithEditor(c.Request.Context()))
+		c.Next()
+	}
+}
+
+// RequireEditor responds with 401 to the requests that don't come from an editor
+func RequireEditor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.IsEditor(c.Request.Context()) {
+			respondUnauthorized(c)
+			return
+		}
+		c.Next()
+	}
+}
+
+func respondUnauthorized(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	problem.Respond(c, problem.Unauthorized())
+	c.Abort()
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/articles"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/comments"
-	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers/errres"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers/problem"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
 	"github.com/gin-gonic/gin"
 )
@@ -27,93 +33,452 @@ func (h *RouteHandler) GetArticleById(c *gin.Context) {
 	idParam, ok := c.Params.Get("id")
 	id, err := strconv.Atoi(idParam)
 	if idParam == "" || !ok || err != nil {
-		log.Printf("No id was found for GetArticleById")
-		c.JSON(http.StatusBadRequest, errres.ArticleIdNotFoundResponse())
+		logger(c).Info("Invalid article id was provided for GetArticleById", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
 		return
 	}
-	article, err := h.articleService.GetArticleById(id)
+	article, err := h.articleService.GetArticleById(c.Request.Context(), id)
 	if err != nil {
-		if err.Error() == articles.NoArticleFoundError {
-			log.Printf("No article was found for id: %s", idParam)
-			c.JSON(http.StatusNotFound, articles.NoArticleFoundError)
-		} else {
-			log.Printf("Encountered an error while getting articles by id: %s", idParam)
-			c.JSON(http.StatusBadRequest, errres.ArticleByIdError(idParam))
-		}
+		respondError(c, err, problem.ArticleFetchFailed(idParam))
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+// GetArticleBySlug responds with the article having the slug, a previous slug of a renamed article
+// redirects permanently to its current slug keeping the query string
+func (h *RouteHandler) GetArticleBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	if slug == "" {
+		logger(c).Info("No article slug was provided for GetArticleBySlug")
+		problem.Respond(c, problem.InvalidArticleSlug())
+		return
+	}
+	article, err := h.articleService.GetArticleBySlug(c.Request.Context(), slug)
+	if err != nil {
+		respondError(c, err, problem.ArticleSlugFetchFailed(slug))
+		return
+	}
+	if article.Slug != slug {
+		location := url.URL{Path: strings.TrimSuffix(c.Request.URL.Path, slug) + article.Slug, RawQuery: c.Request.URL.RawQuery}
+		c.Redirect(http.StatusMovedPermanently, location.String())
 		return
 	}
 	c.JSON(http.StatusOK, article)
 }
 
 func (h *RouteHandler) GetArticles(c *gin.Context) {
-	articles, err := h.articleService.GetArticles()
+	limit, err := queryLimit(c)
+	if err != nil {
+		logger(c).Info("Invalid limit was provided for GetArticles", "limit", c.Query("limit"))
+		problem.Respond(c, problem.InvalidPagination())
+		return
+	}
+	filter := models.ArticleFilter{Tags: c.QueryArray("tag"), Status: models.ArticleStatus(c.Query("status"))}
+	switch c.DefaultQuery("match", "any") {
+	case "any":
+	case "all":
+		filter.MatchAllTags = true
+	default:
+		logger(c).Info("Invalid tags match was provided for GetArticles", "match", c.Query("match"))
+		problem.Respond(c, problem.InvalidTagsMatch())
+		return
+	}
+	if filter.Status != "" && !slices.Contains(models.ArticleStatuses, filter.Status) {
+		logger(c).Info("Invalid status was provided for GetArticles", "status", c.Query("status"))
+		problem.Respond(c, problem.InvalidArticleStatus())
+		return
+	}
+	page, err := h.articleService.GetArticles(c.Request.Context(), limit, c.Query("cursor"), filter)
 	if err != nil {
-		log.Print(err.Error())
-		c.JSON(http.StatusInternalServerError, errres.ArticleGetAllError())
+		respondError(c, err, problem.ArticleListFailed())
 		return
 	}
-	c.JSON(http.StatusOK, articles)
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *RouteHandler) SearchArticles(c *gin.Context) {
+	limit, err := queryLimit(c)
+	if err != nil {
+		logger(c).Info("Invalid limit was provided for SearchArticles", "limit", c.Query("limit"))
+		problem.Respond(c, problem.InvalidPagination())
+		return
+	}
+	page, err := h.articleService.Search(c.Request.Context(), c.Query("q"), limit, c.Query("cursor"))
+	if err != nil {
+		respondError(c, err, problem.ArticleSearchFailed())
+		return
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func (h *RouteHandler) CreateArticle(c *gin.Context) {
 	article := new(models.Article)
-	err := c.BindJSON(article)
+	err := c.ShouldBindJSON(article)
 	if err != nil {
-		log.Print(err.Error())
-		c.JSON(http.StatusBadRequest, errres.ArticleBindingError())
+		logger(c).Info("Invalid article body was provided", "error", err)
+		problem.Respond(c, problem.InvalidArticleBody(err))
 		return
 	}
-	err = h.articleService.CreateArticle(article)
+	err = h.articleService.CreateArticle(c.Request.Context(), article)
 	if err != nil {
-		log.Print(err.Error())
-		c.JSON(http.StatusInternalServerError, errres.ArticleCreationError())
+		respondError(c, err, problem.ArticleCreationFailed())
 		return
 	}
-	c.Status(http.StatusCreated)
+	respondCreated(c, article.Id, article)
+}
+
+func (h *RouteHandler) UpdateArticle(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for UpdateArticle", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	article := new(models.Article)
+	err = c.ShouldBindJSON(article)
+	if err != nil {
+		logger(c).Info("Invalid article body was provided", "error", err)
+		problem.Respond(c, problem.InvalidArticleBody(err))
+		return
+	}
+	article.Id = id
+	err = h.articleService.UpdateArticle(c.Request.Context(), article)
+	if err != nil {
+		respondError(c, err, problem.ArticleUpdateFailed())
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *RouteHandler) PatchArticle(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for PatchArticle", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	patch := new(models.ArticlePatch)
+	err = c.ShouldBindJSON(patch)
+	if err != nil {
+		logger(c).Info("Invalid article body was provided", "error", err)
+		problem.Respond(c, problem.InvalidArticleBody(err))
+		return
+	}
+	article, err := h.articleService.PatchArticle(c.Request.Context(), id, patch)
+	if err != nil {
+		respondError(c, err, problem.ArticleUpdateFailed())
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *RouteHandler) DeleteArticle(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for DeleteArticle", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	err = h.articleService.DeleteArticle(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, problem.ArticleDeletionFailed())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RouteHandler) ScheduleArticle(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for ScheduleArticle", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	schedule := new(models.ArticleSchedule)
+	if err = c.ShouldBindJSON(schedule); err != nil {
+		logger(c).Info("Invalid article schedule was provided", "error", err)
+		problem.Respond(c, problem.InvalidArticleSchedule(err))
+		return
+	}
+	article, err := h.articleService.ScheduleArticle(c.Request.Context(), id, schedule)
+	if err != nil {
+		respondError(c, err, problem.ArticleTransitionFailed())
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *RouteHandler) PublishArticle(c *gin.Context) {
+	h.transitionArticle(c, "PublishArticle", h.articleService.PublishArticle)
+}
+
+func (h *RouteHandler) UnpublishArticle(c *gin.Context) {
+	h.transitionArticle(c, "UnpublishArticle", h.articleService.UnpublishArticle)
+}
+
+func (h *RouteHandler) ArchiveArticle(c *gin.Context) {
+	h.transitionArticle(c, "ArchiveArticle", h.articleService.ArchiveArticle)
+}
+
+// transitionArticle applies the transition on the article of the id param and responds with the article in its new status
+func (h *RouteHandler) transitionArticle(c *gin.Context, name string, transition func(ctx context.Context, id int) (*models.Article, error)) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for "+name, "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	article, err := transition(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err, problem.ArticleTransitionFailed())
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *RouteHandler) GetArticleRevisions(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for GetArticleRevisions", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	limit, err := queryLimit(c)
+	if err != nil {
+		logger(c).Info("Invalid limit was provided for GetArticleRevisions", "limit", c.Query("limit"))
+		problem.Respond(c, problem.InvalidPagination())
+		return
+	}
+	page, err := h.articleService.GetArticleRevisions(c.Request.Context(), id, limit, c.Query("cursor"))
+	if err != nil {
+		respondError(c, err, problem.RevisionFetchFailed())
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *RouteHandler) GetArticleRevision(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for GetArticleRevision", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	revision, err := revisionParam(c.Param("revision"))
+	if err != nil {
+		logger(c).Info("Invalid revision was provided for GetArticleRevision", "revision", c.Param("revision"))
+		problem.Respond(c, problem.InvalidRevision())
+		return
+	}
+	result, err := h.articleService.GetArticleRevision(c.Request.Context(), id, revision)
+	if err != nil {
+		respondError(c, err, problem.RevisionFetchFailed())
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DiffArticleRevisions responds with the unified diff of the article between the from and to revisions as text/x-diff
+// its title, tags and status are diffed as header lines before its content
+func (h *RouteHandler) DiffArticleRevisions(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for DiffArticleRevisions", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	from, fromErr := revisionParam(c.Query("from"))
+	to, toErr := revisionParam(c.Query("to"))
+	if fromErr != nil || toErr != nil {
+		logger(c).Info("Invalid revisions were provided for DiffArticleRevisions", "from", c.Query("from"), "to", c.Query("to"))
+		problem.Respond(c, problem.InvalidRevision())
+		return
+	}
+	diff, err := h.articleService.DiffArticleRevisions(c.Request.Context(), id, from, to)
+	if err != nil {
+		respondError(c, err, problem.RevisionDiffFailed())
+		return
+	}
+	c.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(diff))
+}
+
+func (h *RouteHandler) RestoreArticleRevision(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	id, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for RestoreArticleRevision", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	revision, err := revisionParam(c.Param("revision"))
+	if err != nil {
+		logger(c).Info("Invalid revision was provided for RestoreArticleRevision", "revision", c.Param("revision"))
+		problem.Respond(c, problem.InvalidRevision())
+		return
+	}
+	article, err := h.articleService.RestoreArticleRevision(c.Request.Context(), id, revision)
+	if err != nil {
+		respondError(c, err, problem.RevisionRestoreFailed())
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+func (h *RouteHandler) GetTags(c *gin.Context) {
+	tags, err := h.articleService.GetTags(c.Request.Context())
+	if err != nil {
+		respondError(c, err, problem.TagListFailed())
+		return
+	}
+	c.JSON(http.StatusOK, tags)
 }
 
 func (h *RouteHandler) CreateComment(c *gin.Context) {
 	idParam, ok := c.Params.Get("id")
 	articleId, err := strconv.Atoi(idParam)
 	if idParam == "" || !ok || err != nil {
-		log.Printf("No id was provided for CreateComment")
-		c.JSON(http.StatusBadRequest, errres.ArticleIdNotFoundResponse())
+		logger(c).Info("Invalid article id was provided for CreateComment", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
 		return
 	}
 	comment := new(models.Comment)
-	err = c.BindJSON(comment)
+	err = c.ShouldBindJSON(comment)
 	if err != nil {
-		log.Print(err.Error())
-		c.JSON(http.StatusBadRequest, errres.CommentBindingError())
+		logger(c).Info("Invalid comment body was provided", "error", err)
+		problem.Respond(c, problem.InvalidCommentBody(err))
 		return
 	}
 	comment.ArticleId = articleId
-	err = h.commentService.CreateComment(comment)
+	err = h.commentService.CreateComment(c.Request.Context(), comment)
 	if err != nil {
-		if err.Error() == comments.NoArticleIdProvidedErrorContent {
-			log.Print(err.Error())
-			c.JSON(http.StatusBadRequest, errres.CommentInvalidArticleIdProvidedError())
-		}
-		log.Print(err.Error())
-		c.JSON(http.StatusInternalServerError, errres.CommentCreationError())
+		respondError(c, err, problem.CommentCreationFailed())
 		return
 	}
-	c.Status(http.StatusCreated)
+	respondCreated(c, comment.Id, comment)
 }
 
-func (h *RouteHandler) GetCommentsForArticle(c *gin.Context) {
+func (h *RouteHandler) GetCommentById(c *gin.Context) {
 	idParam, ok := c.Params.Get("id")
 	articleId, err := strconv.Atoi(idParam)
 	if idParam == "" || !ok || err != nil {
-		log.Printf("No id was provided for CreateComment")
-		c.JSON(http.StatusBadRequest, errres.ArticleIdNotFoundResponse())
+		logger(c).Info("Invalid article id was provided for GetCommentById", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
 		return
 	}
-	comments, err := h.commentService.GetCommentsByArticleId(articleId)
+	commentIdParam, ok := c.Params.Get("commentId")
+	id, err := strconv.Atoi(commentIdParam)
+	if commentIdParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid comment id was provided for GetCommentById", "id", commentIdParam)
+		problem.Respond(c, problem.InvalidCommentId())
+		return
+	}
+	comment, err := h.commentService.GetCommentById(c.Request.Context(), articleId, id)
 	if err != nil {
-		log.Print(err.Error())
-		c.JSON(http.StatusInternalServerError, errres.ArticleGetAllError())
+		respondError(c, err, problem.CommentFetchFailed(commentIdParam))
+		return
+	}
+	c.JSON(http.StatusOK, comment)
+}
+
+func (h *RouteHandler) GetCommentsForArticle(c *gin.Context) {
+	idParam, ok := c.Params.Get("id")
+	articleId, err := strconv.Atoi(idParam)
+	if idParam == "" || !ok || err != nil {
+		logger(c).Info("Invalid article id was provided for GetCommentsForArticle", "id", idParam)
+		problem.Respond(c, problem.InvalidArticleId())
+		return
+	}
+	sort := models.CommentSort(c.DefaultQuery("sort", string(models.CommentSortOldest)))
+	if sort != models.CommentSortOldest && sort != models.CommentSortNewest {
+		logger(c).Info("Invalid sort was provided for GetCommentsForArticle", "sort", c.Query("sort"))
+		problem.Respond(c, problem.InvalidCommentsSort())
 		return
 	}
-	c.JSON(http.StatusOK, comments)
+	switch c.DefaultQuery("view", "flat") {
+	case "flat":
+		limit, err := queryLimit(c)
+		if err != nil {
+			logger(c).Info("Invalid limit was provided for GetCommentsForArticle", "limit", c.Query("limit"))
+			problem.Respond(c, problem.InvalidPagination())
+			return
+		}
+		page, err := h.commentService.GetComments(c.Request.Context(), articleId, sort, limit, c.Query("cursor"))
+		if err != nil {
+			respondError(c, err, problem.CommentListFailed(idParam))
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	case "tree":
+		depth, err := strconv.Atoi(c.DefaultQuery("depth", "0"))
+		if err != nil || depth < 0 {
+			logger(c).Info("Invalid depth was provided for GetCommentsForArticle", "depth", c.Query("depth"))
+			problem.Respond(c, problem.InvalidCommentsDepth())
+			return
+		}
+		tree, err := h.commentService.GetCommentTreeByArticleId(c.Request.Context(), articleId, depth, sort)
+		if err != nil {
+			respondError(c, err, problem.CommentListFailed(idParam))
+			return
+		}
+		c.JSON(http.StatusOK, tree)
+	default:
+		logger(c).Info("Invalid view was provided for GetCommentsForArticle", "view", c.Query("view"))
+		problem.Respond(c, problem.InvalidCommentsView())
+	}
+}
+
+// respondError is the single place where service errors are translated to responses,
+// domain errors are mapped by their kind and any other error is responded to with the fallback problem
+// server errors are logged as errors while client ones are only informative
+func respondError(c *gin.Context, err error, fallback *problem.Problem) {
+	p := problem.FromError(err, fallback)
+	level := slog.LevelInfo
+	if p.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger(c).Log(c.Request.Context(), level, "Request failed", "error", err, "code", p.Code)
+	problem.Respond(c, p)
+}
+
+// logger returns the request's logger for the handlers package
+func logger(c *gin.Context) *slog.Logger {
+	return logging.For(c.Request.Context(), "handlers")
+}
+
+// respondCreated responds with the created resource and its Location, which is the path of the collection it was posted to
+// followed by its id
+func respondCreated(c *gin.Context, id int, resource any) {
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+strconv.Itoa(id))
+	c.JSON(http.StatusCreated, resource)
+}
+
+// queryLimit parses the optional limit query param, a missing limit is returned as 0
+func queryLimit(c *gin.Context) (int, error) {
+	limitParam := c.Query("limit")
+	if limitParam == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 0 {
+		return 0, errors.New("limit must be a non-negative number")
+	}
+	return limit, nil
+}
+
+// revisionParam parses a revision number, revisions start at 1
+func revisionParam(param string) (int, error) {
+	revision, err := strconv.Atoi(param)
+	if err != nil || revision < 1 {
+		return 0, errors.New("revision must be a positive number")
+	}
+	return revision, nil
 }
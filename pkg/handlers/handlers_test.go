@@ -2,29 +2,57 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/articles"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/auth"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/comments"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/config"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers/problem"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/health"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/scheduler"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/validation"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var recorder *httptest.ResponseRecorder
-var context *gin.Context
+var ginContext *gin.Context
 
 type mockArticleService struct {
 	CreateArticleCalled bool
+	DeleteArticleCalled bool
+	Filter              models.ArticleFilter
 }
 type mockCommentService struct {
 	CalledCreateComment bool
 }
 
+const missingArticleId = 404
+const missingCommentId = 404
+const createdId = 7
+const archivedArticleId = 409
+const missingRevision = 404
+const failingArticleId = 500
+const articleSlug = "awesome"
+const renamedSlug = "old-awesome"
+
 var routeHandler = &RouteHandler{articleService: &mockArticleService{}, commentService: &mockCommentService{}}
 
 func TestMain(m *testing.M) {
@@ -36,17 +64,17 @@ func TestMain(m *testing.M) {
 func TestGetArticleByIdShouldReturn400(t *testing.T) {
 	defer initContext()
 	t.Run("No ID provided", func(t *testing.T) {
-		routeHandler.GetArticleById(context) // No ID param provided
+		routeHandler.GetArticleById(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById without an ID it must return 400 error")
 	})
 	t.Run("Empty ID provided", func(t *testing.T) {
-		context.AddParam("id", "")
-		routeHandler.GetArticleById(context) // No ID param provided
+		ginContext.AddParam("id", "")
+		routeHandler.GetArticleById(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an empty ID it must return 400 error")
 	})
 	t.Run("Non-numeric ID provided", func(t *testing.T) {
-		context.AddParam("id", "ABC")
-		routeHandler.GetArticleById(context) // No ID param provided
+		ginContext.AddParam("id", "ABC")
+		routeHandler.GetArticleById(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an non numeric ID it must return 400 error")
 	})
 }
@@ -54,10 +82,10 @@ func TestGetArticleByIdShouldReturn400(t *testing.T) {
 func TestGetArticleById(t *testing.T) {
 	// Given
 	defer initContext()
-	context.AddParam("id", "1")
+	ginContext.AddParam("id", "1")
 
 	// When
-	routeHandler.GetArticleById(context)
+	routeHandler.GetArticleById(ginContext)
 
 	// Then
 	expected, _ := json.Marshal(validArticle(1))
@@ -65,51 +93,655 @@ func TestGetArticleById(t *testing.T) {
 	assert.Equal(t, http.StatusOK, recorder.Code)
 }
 
+func TestGetArticleByIdShouldReturnProblemDetailsFor404(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{URL: &url.URL{Path: "/v1/articles/404"}}
+	ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+
+	// When
+	routeHandler.GetArticleById(ginContext)
+
+	// Then
+	expected, _ := json.Marshal(problem.Problem{
+		Type:     problem.TypeUriPrefix + "article_not_found",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "No article was found for id: 404",
+		Instance: "/v1/articles/404",
+		Code:     "article_not_found",
+	})
+	assert.Equal(t, string(expected), recorder.Body.String())
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, problem.ContentType, recorder.Header().Get("Content-Type"))
+}
+
+func TestGetArticleByIdShouldReturn500ForUnexpectedErrors(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.AddParam("id", strconv.Itoa(failingArticleId))
+
+	// When
+	routeHandler.GetArticleById(ginContext)
+
+	// Then
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"code":"article_fetch_failed"`)
+}
+
+func TestGetArticleBySlug(t *testing.T) {
+	t.Run("Current slug", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("slug", articleSlug)
+		routeHandler.GetArticleBySlug(ginContext)
+		expected, _ := json.Marshal(sluggedArticle())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, string(expected), recorder.Body.String())
+	})
+	t.Run("Previous slug", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{Method: http.MethodGet, URL: &url.URL{Path: "/v1/articles/by-slug/" + renamedSlug, RawQuery: "utm_source=feed"}}
+		ginContext.AddParam("slug", renamedSlug)
+		routeHandler.GetArticleBySlug(ginContext)
+		ginContext.Writer.WriteHeaderNow()
+		assert.Equal(t, http.StatusMovedPermanently, recorder.Code, "A previous slug must redirect to the current one")
+		assert.Equal(t, "/v1/articles/by-slug/"+articleSlug+"?utm_source=feed", recorder.Header().Get("Location"), "The query string must be kept")
+	})
+	t.Run("Missing slug", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("slug", "missing")
+		routeHandler.GetArticleBySlug(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "No article was found for slug: missing")
+	})
+	t.Run("No slug", func(t *testing.T) {
+		defer initContext()
+		routeHandler.GetArticleBySlug(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
 func TestGetArticles(t *testing.T) {
 	// Given
 	defer initContext()
+	ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "limit=2"}}
 
 	// When
-	routeHandler.GetArticles(context)
+	routeHandler.GetArticles(ginContext)
 
 	// Then
-	expected, _ := json.Marshal([]models.Article{*validArticle(1), *validArticle(2)})
+	expected, _ := json.Marshal(models.ArticlePage{Articles: []models.Article{*validArticle(1), *validArticle(2)}, NextCursor: "next"})
 	assert.Equal(t, string(expected), string(recorder.Body.String()))
 	assert.Equal(t, http.StatusOK, recorder.Code)
 }
 
+func TestGetArticlesShouldFilter(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected models.ArticleFilter
+	}{
+		{"tag=go&tag=postgres", models.ArticleFilter{Tags: []string{"go", "postgres"}}},
+		{"tag=go&tag=postgres&match=all", models.ArticleFilter{Tags: []string{"go", "postgres"}, MatchAllTags: true}},
+		{"status=draft", models.ArticleFilter{Status: models.StatusDraft}},
+	} {
+		t.Run(test.query, func(t *testing.T) {
+			defer initContext()
+			defer routeHandler.articleService.(*mockArticleService).Reset()
+			ginContext.Request = &http.Request{URL: &url.URL{RawQuery: test.query}}
+			routeHandler.GetArticles(ginContext)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expected, routeHandler.articleService.(*mockArticleService).Filter)
+		})
+	}
+	t.Run("Unknown match provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "tag=go&match=some"}}
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticles with an unknown match it must return 400 error")
+	})
+	t.Run("Unknown status provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "status=deleted"}}
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticles with an unknown status it must return 400 error")
+	})
+}
+
+func TestGetTags(t *testing.T) {
+	// Given
+	defer initContext()
+
+	// When
+	routeHandler.GetTags(ginContext)
+
+	// Then
+	assert.Equal(t, `[{"name":"go","count":2},{"name":"postgres","count":1}]`, recorder.Body.String())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestGetArticlesShouldReturn400ForInvalidPagination(t *testing.T) {
+	t.Run("Non-numeric limit provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "limit=ABC"}}
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticles with a non numeric limit it must return 400 error")
+	})
+	t.Run("Negative limit provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "limit=-1"}}
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticles with a negative limit it must return 400 error")
+	})
+	t.Run("Invalid cursor provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "cursor=invalid"}}
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticles with an invalid cursor it must return 400 error")
+	})
+}
+
+func TestGetArticlesShouldMapContextErrors(t *testing.T) {
+	t.Run("Deadline exceeded", func(t *testing.T) {
+		defer initContext()
+		ctx, cancel := context.WithTimeout(context.Background(), 0)
+		defer cancel()
+		ginContext.Request = ginContext.Request.WithContext(ctx)
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, http.StatusGatewayTimeout, recorder.Code, "When the request's deadline is exceeded it must return 504 error")
+	})
+	t.Run("Client closed the request", func(t *testing.T) {
+		defer initContext()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		ginContext.Request = ginContext.Request.WithContext(ctx)
+		routeHandler.GetArticles(ginContext)
+		assert.Equal(t, problem.StatusClientClosedRequest, recorder.Code, "When the request is canceled it must return 499 error")
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	// Given
+	engine := gin.New()
+	engine.Use(RequestTimeout(time.Minute))
+	var deadline time.Time
+	engine.GET("/", func(c *gin.Context) {
+		deadline, _ = c.Request.Context().Deadline()
+	})
+
+	// When
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	// Then
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second, "The request's context must have the configured deadline")
+}
+
+func TestRequestId(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+	engine := gin.New()
+	engine.Use(RequestId(logging.New(&out, logging.Levels{Default: slog.LevelInfo})))
+	engine.GET("/", func(c *gin.Context) {
+		logger(c).Info("Handling")
+	})
+	t.Run("The provided id is kept", func(t *testing.T) {
+		defer out.Reset()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set(RequestIdHeader, "abc-123")
+		response := httptest.NewRecorder()
+		engine.ServeHTTP(response, request)
+		assert.Equal(t, "abc-123", response.Header().Get(RequestIdHeader), "The provided request id must be echoed")
+		assert.Equal(t, 2, strings.Count(out.String(), `"request_id":"abc-123"`), "Both the handler's and the access log lines must carry the request id")
+	})
+	t.Run("An invalid id is replaced", func(t *testing.T) {
+		defer out.Reset()
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		request.Header.Set(RequestIdHeader, "has spaces")
+		response := httptest.NewRecorder()
+		engine.ServeHTTP(response, request)
+		assert.Len(t, response.Header().Get(RequestIdHeader), 32, "A new request id must be generated")
+	})
+}
+
+func TestSearchArticles(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "q=awesome"}}
+
+	// When
+	routeHandler.SearchArticles(ginContext)
+
+	// Then
+	expected, _ := json.Marshal(models.ArticleSearchPage{Results: []models.ArticleSearchResult{
+		{Article: *validArticle(1), Rank: 0.5, Snippet: "<mark>Awesome</mark> article is <mark>awesome</mark>"},
+	}})
+	assert.Equal(t, string(expected), recorder.Body.String())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestSearchArticlesShouldReturn400ForMissingQuery(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{URL: &url.URL{}}
+
+	// When
+	routeHandler.SearchArticles(ginContext)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling SearchArticles without a query it must return 400 error")
+}
+
 func TestCreateArticle(t *testing.T) {
 	// Given
 	defer initContext()
 	defer routeHandler.articleService.(*mockArticleService).Reset()
 
-	body, _ := json.Marshal(validArticle(1))
-	context.Request = &http.Request{
-		URL:  &url.URL{},
+	body, _ := json.Marshal(validArticle(0))
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{Path: "/v1/articles"},
 		Body: io.NopCloser(bytes.NewBuffer(body)),
 	}
 
 	// When
-	routeHandler.CreateArticle(context)
+	routeHandler.CreateArticle(ginContext)
 
 	// Then
 	assert.True(t, routeHandler.articleService.(*mockArticleService).CreateArticleCalled, "Should call articleService.CreateArticle with a valid request")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "/v1/articles/"+strconv.Itoa(createdId), recorder.Header().Get("Location"))
+	expected, _ := json.Marshal(validArticle(createdId))
+	assert.JSONEq(t, string(expected), recorder.Body.String(), "The created article must be returned with its id")
+}
+
+func TestUpdateArticle(t *testing.T) {
+	// Given
+	defer initContext()
+
+	body, _ := json.Marshal(models.Article{Title: "Updated", Content: "Updated content"})
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBuffer(body)),
+	}
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.UpdateArticle(ginContext)
+
+	// Then
+	expected, _ := json.Marshal(models.Article{Id: 1, Title: "Updated", Content: "Updated content"})
+	assert.Equal(t, string(expected), recorder.Body.String())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestUpdateArticleShouldReturnFieldErrorsForWrongTypes(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBufferString(`{"title": 42}`)),
+	}
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.UpdateArticle(ginContext)
+
+	// Then
+	response := new(problem.Problem)
+	json.Unmarshal(recorder.Body.Bytes(), response)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "invalid_article_body", response.Code)
+	assert.Equal(t, []problem.FieldError{{Field: "title", Code: "invalid_type", Message: "must be of type string"}}, response.Errors)
+}
+
+func TestUpdateArticleShouldReturn404ForMissingArticle(t *testing.T) {
+	// Given
+	defer initContext()
+
+	body, _ := json.Marshal(models.Article{Title: "Updated", Content: "Updated content"})
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBuffer(body)),
+	}
+	ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+
+	// When
+	routeHandler.UpdateArticle(ginContext)
+
+	// Then
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestPatchArticle(t *testing.T) {
+	// Given
+	defer initContext()
+
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBufferString(`{"title": "Patched"}`)),
+	}
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.PatchArticle(ginContext)
+
+	// Then
+	expectedArticle := validArticle(1)
+	expectedArticle.Title = "Patched"
+	expected, _ := json.Marshal(expectedArticle)
+	assert.Equal(t, string(expected), recorder.Body.String())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestDeleteArticle(t *testing.T) {
+	// Given
+	defer initContext()
+	defer routeHandler.articleService.(*mockArticleService).Reset()
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.DeleteArticle(ginContext)
+
+	// Then
+	assert.True(t, routeHandler.articleService.(*mockArticleService).DeleteArticleCalled, "Should call articleService.DeleteArticle with a valid id")
+	assert.Equal(t, http.StatusNoContent, ginContext.Writer.Status())
+}
+
+func TestDeleteArticleShouldReturn404ForMissingArticle(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+
+	// When
+	routeHandler.DeleteArticle(ginContext)
+
+	// Then
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestArticleTransitions(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		handler  gin.HandlerFunc
+		expected models.ArticleStatus
+	}{
+		{"Publish", routeHandler.PublishArticle, models.StatusPublished},
+		{"Unpublish", routeHandler.UnpublishArticle, models.StatusDraft},
+		{"Archive", routeHandler.ArchiveArticle, models.StatusArchived},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer initContext()
+			ginContext.AddParam("id", "1")
+			test.handler(ginContext)
+			var article models.Article
+			json.Unmarshal(recorder.Body.Bytes(), &article)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expected, article.Status, "The article must be returned in its new status")
+		})
+	}
+	t.Run("Invalid transition", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", strconv.Itoa(archivedArticleId))
+		routeHandler.ArchiveArticle(ginContext)
+		assert.Equal(t, http.StatusConflict, recorder.Code, "A transition that the article's status doesn't allow must return 409 error")
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_status_transition"`)
+	})
+	t.Run("Missing article", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+		routeHandler.PublishArticle(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+	t.Run("Non-numeric ID provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "ABC")
+		routeHandler.PublishArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+}
+
+func TestScheduleArticle(t *testing.T) {
+	t.Run("Scheduled", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.Request = &http.Request{URL: &url.URL{}, Body: io.NopCloser(strings.NewReader(`{"publish_at":"2030-01-02T10:00:00Z"}`))}
+		routeHandler.ScheduleArticle(ginContext)
+		var article models.Article
+		json.Unmarshal(recorder.Body.Bytes(), &article)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, models.StatusScheduled, article.Status)
+		assert.Equal(t, time.Date(2030, 1, 2, 10, 0, 0, 0, time.UTC), *article.PublishAt)
+	})
+	t.Run("Missing publish_at", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.Request = &http.Request{URL: &url.URL{}, Body: io.NopCloser(strings.NewReader(`{}`))}
+		routeHandler.ScheduleArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_publish_at"`)
+	})
+	t.Run("Invalid publish_at", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.Request = &http.Request{URL: &url.URL{}, Body: io.NopCloser(strings.NewReader(`{"publish_at":"tomorrow"}`))}
+		routeHandler.ScheduleArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_article_schedule"`)
+	})
+}
+
+func TestArticleRevisions(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		routeHandler.GetArticleRevisions(ginContext)
+		expected, _ := json.Marshal(models.ArticleRevisionPage{Revisions: []models.ArticleRevision{*validRevision(1, 2), *validRevision(1, 1)}})
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, string(expected), recorder.Body.String())
+	})
+	t.Run("List of a missing article", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+		routeHandler.GetArticleRevisions(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+	t.Run("Get", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("revision", "2")
+		routeHandler.GetArticleRevision(ginContext)
+		expected, _ := json.Marshal(validRevision(1, 2))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.JSONEq(t, string(expected), recorder.Body.String())
+	})
+	t.Run("Get a missing revision", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("revision", strconv.Itoa(missingRevision))
+		routeHandler.GetArticleRevision(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"revision_not_found"`)
+	})
+	t.Run("Invalid revision", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("revision", "0")
+		routeHandler.GetArticleRevision(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_revision"`)
+	})
+	t.Run("Diff", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "from=1&to=2"}}
+		routeHandler.DiffArticleRevisions(ginContext)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/x-diff; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "--- revisions/1\n+++ revisions/2\n@@ -1 +1 @@\n-Awesome\n+Awesome!\n", recorder.Body.String())
+	})
+	t.Run("Diff without to", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "from=1"}}
+		routeHandler.DiffArticleRevisions(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"code":"invalid_revision"`)
+	})
+	t.Run("Restore", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("revision", "1")
+		routeHandler.RestoreArticleRevision(ginContext)
+		var article models.Article
+		json.Unmarshal(recorder.Body.Bytes(), &article)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, validRevision(1, 1).Content, article.Content)
+	})
+	t.Run("Restore a missing revision", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("revision", strconv.Itoa(missingRevision))
+		routeHandler.RestoreArticleRevision(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	publishAt := time.Now().Add(-time.Minute)
+	article := &models.Article{Title: "Awesome", Content: "Awesome", Status: models.StatusScheduled, PublishAt: &publishAt}
+	repo.CreateArticle(context.Background(), article)
+	handler := NewSchedulerHandler(scheduler.New(repo, time.Minute, 10))
+
+	t.Run("Manual run", func(t *testing.T) {
+		defer initContext()
+		handler.Run(ginContext)
+		var run scheduler.Run
+		json.Unmarshal(recorder.Body.Bytes(), &run)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, scheduler.TriggerManual, run.Trigger)
+		assert.Equal(t, []int{article.Id}, run.Published)
+	})
+	t.Run("State", func(t *testing.T) {
+		defer initContext()
+		handler.State(ginContext)
+		var state scheduler.State
+		json.Unmarshal(recorder.Body.Bytes(), &state)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.False(t, state.Running, "The scheduler wasn't started")
+		assert.Equal(t, 1, state.Runs)
+		assert.Equal(t, 1, state.PublishedTotal)
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	// Given
+	newEngine := func(editorToken string) *gin.Engine {
+		engine := gin.New()
+		engine.Use(Authenticate(editorToken))
+		engine.GET("/", func(c *gin.Context) {
+			c.String(http.StatusOK, strconv.FormatBool(auth.IsEditor(c.Request.Context())))
+		})
+		engine.POST("/", RequireEditor(), func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		})
+		return engine
+	}
+	serve := func(engine *gin.Engine, method string, authorization string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, "/", nil)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response := httptest.NewRecorder()
+		engine.ServeHTTP(response, request)
+		return response
+	}
+	engine := newEngine("s3cr3t")
+
+	t.Run("Anonymous reader", func(t *testing.T) {
+		response := serve(engine, http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "false", response.Body.String())
+	})
+	t.Run("Editor", func(t *testing.T) {
+		response := serve(engine, http.MethodGet, "Bearer s3cr3t")
+		assert.Equal(t, "true", response.Body.String())
+		assert.Equal(t, http.StatusCreated, serve(engine, http.MethodPost, "Bearer s3cr3t").Code)
+	})
+	t.Run("Wrong token", func(t *testing.T) {
+		response := serve(engine, http.MethodGet, "Bearer guess")
+		assert.Equal(t, http.StatusUnauthorized, response.Code, "A wrong token must return 401 error even on public routes")
+		assert.Equal(t, "Bearer", response.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, problem.ContentType, response.Header().Get("Content-Type"))
+	})
+	t.Run("Anonymous writer", func(t *testing.T) {
+		response := serve(engine, http.MethodPost, "")
+		assert.Equal(t, http.StatusUnauthorized, response.Code, "Anonymous readers must not be allowed to write articles")
+	})
+	t.Run("No editor token", func(t *testing.T) {
+		engine := newEngine("")
+		response := serve(engine, http.MethodGet, "")
+		assert.Equal(t, "false", response.Body.String(), "Every request must be anonymous when there's no editor token")
+		assert.Equal(t, http.StatusUnauthorized, serve(engine, http.MethodPost, "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(engine, http.MethodGet, "Bearer ").Code, "No token must match an empty editor token")
+	})
+}
+
+func TestAnonymousListingShouldHideDraftsWithTheDefaultConfig(t *testing.T) {
+	// Given a draft and a published article served with the default configuration
+	repo := repository.NewMemoryRepository()
+	require.NoError(t, repo.CreateArticle(context.Background(), &models.Article{Title: "Draft", Content: "Draft", Status: models.StatusDraft}))
+	published := &models.Article{Title: "Published", Content: "Published", Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(context.Background(), published))
+	handler := NewRouteHandler(articles.NewArticleService(repo, 0), &mockCommentService{})
+	engine := gin.New()
+	engine.Use(Authenticate(config.Default().Auth.EditorToken))
+	engine.GET("/v1/articles", handler.GetArticles)
+
+	// When
+	response := httptest.NewRecorder()
+	engine.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/v1/articles", nil))
+
+	// Then
+	require.Equal(t, http.StatusOK, response.Code)
+	var page models.ArticlePage
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &page))
+	require.Len(t, page.Articles, 1, "Anonymous readers must only get the published articles")
+	assert.Equal(t, published.Id, page.Articles[0].Id)
+}
+
+func TestCreateArticleShouldReturn422ForInvalidFields(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBufferString(`{"title": "  ", "content": "Awesome article is awesome"}`)),
+	}
+
+	// When
+	routeHandler.CreateArticle(ginContext)
+
+	// Then
+	response := new(problem.Problem)
+	json.Unmarshal(recorder.Body.Bytes(), response)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, []problem.FieldError{{Field: "title", Code: "required", Message: "is required"}}, response.Errors)
 }
 
 func TestCreateCommentShouldReturn400ForWrongId(t *testing.T) {
 	defer initContext()
 	t.Run("No ID provided", func(t *testing.T) {
-		routeHandler.CreateComment(context) // No ID param provided
+		routeHandler.CreateComment(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById without an ID it must return 400 error")
 	})
 	t.Run("Empty ID provided", func(t *testing.T) {
-		context.AddParam("id", "")
-		routeHandler.CreateComment(context) // No ID param provided
+		ginContext.AddParam("id", "")
+		routeHandler.CreateComment(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an empty ID it must return 400 error")
 	})
 	t.Run("Non-numeric ID provided", func(t *testing.T) {
-		context.AddParam("id", "ABC")
-		routeHandler.CreateComment(context) // No ID param provided
+		ginContext.AddParam("id", "ABC")
+		routeHandler.CreateComment(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an non numeric ID it must return 400 error")
 	})
 }
@@ -119,34 +751,64 @@ func TestCreateComment(t *testing.T) {
 	defer initContext()
 	defer routeHandler.commentService.(*mockCommentService).Reset()
 
-	body, _ := json.Marshal(validComment(1))
-	context.Request = &http.Request{
-		URL:  &url.URL{},
+	body, _ := json.Marshal(validComment(0))
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{Path: "/v1/articles/1/comments"},
 		Body: io.NopCloser(bytes.NewBuffer(body)),
 	}
-	context.AddParam("id", "1")
+	ginContext.AddParam("id", "1")
 
 	// When
-	routeHandler.CreateComment(context)
+	routeHandler.CreateComment(ginContext)
 
 	// Then
 	assert.True(t, routeHandler.commentService.(*mockCommentService).CalledCreateComment, "Should call comments.CreateComment with a valid request")
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "/v1/articles/1/comments/"+strconv.Itoa(createdId), recorder.Header().Get("Location"))
+	expected, _ := json.Marshal(validComment(createdId))
+	assert.JSONEq(t, string(expected), recorder.Body.String(), "The created comment must be returned with its id")
+}
+
+func TestGetCommentById(t *testing.T) {
+	t.Run("Existing comment", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("commentId", "2")
+		routeHandler.GetCommentById(ginContext)
+		expected, _ := json.Marshal(validComment(2))
+		assert.Equal(t, string(expected), recorder.Body.String())
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+	t.Run("Missing comment", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("commentId", strconv.Itoa(missingCommentId))
+		routeHandler.GetCommentById(ginContext)
+		assert.Equal(t, http.StatusNotFound, recorder.Code, "When calling GetCommentById for a missing comment it must return 404 error")
+	})
+	t.Run("Non-numeric comment ID provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.AddParam("id", "1")
+		ginContext.AddParam("commentId", "ABC")
+		routeHandler.GetCommentById(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetCommentById with a non numeric ID it must return 400 error")
+	})
 }
 
 func TestGetCommentsForArticleShouldReturn400ForWrongId(t *testing.T) {
 	defer initContext()
 	t.Run("No ID provided", func(t *testing.T) {
-		routeHandler.GetCommentsForArticle(context) // No ID param provided
+		routeHandler.GetCommentsForArticle(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById without an ID it must return 400 error")
 	})
 	t.Run("Empty ID provided", func(t *testing.T) {
-		context.AddParam("id", "")
-		routeHandler.GetCommentsForArticle(context) // No ID param provided
+		ginContext.AddParam("id", "")
+		routeHandler.GetCommentsForArticle(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an empty ID it must return 400 error")
 	})
 	t.Run("Non-numeric ID provided", func(t *testing.T) {
-		context.AddParam("id", "ABC")
-		routeHandler.GetCommentsForArticle(context) // No ID param provided
+		ginContext.AddParam("id", "ABC")
+		routeHandler.GetCommentsForArticle(ginContext) // No ID param provided
 		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetArticleById with an non numeric ID it must return 400 error")
 	})
 }
@@ -154,45 +816,317 @@ func TestGetCommentsForArticleShouldReturn400ForWrongId(t *testing.T) {
 func TestGetCommentsForArticle(t *testing.T) {
 	// Given
 	defer initContext()
-	context.AddParam("id", "1")
+	ginContext.Request = &http.Request{URL: &url.URL{}}
+	ginContext.AddParam("id", "1")
 
 	// When
-	routeHandler.GetCommentsForArticle(context)
+	routeHandler.GetCommentsForArticle(ginContext)
 
 	// Then
-	expected, _ := json.Marshal([]models.Comment{*validComment(1), *validComment(2)})
+	expected, _ := json.Marshal(models.CommentPage{Comments: []models.Comment{*validComment(1), *validComment(2)}})
 	assert.Equal(t, string(expected), string(recorder.Body.String()))
 	assert.Equal(t, http.StatusOK, recorder.Code)
 }
 
+func TestGetCommentsForArticleShouldReturn404ForMissingArticle(t *testing.T) {
+	for _, view := range []string{"flat", "tree"} {
+		t.Run(view, func(t *testing.T) {
+			defer initContext()
+			ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "view=" + view}}
+			ginContext.AddParam("id", strconv.Itoa(missingArticleId))
+			routeHandler.GetCommentsForArticle(ginContext)
+			assert.Equal(t, http.StatusNotFound, recorder.Code, "When calling GetCommentsForArticle for a missing article it must return 404 error")
+		})
+	}
+}
+
+func TestGetCommentsForArticleAsTree(t *testing.T) {
+	// Given
+	defer initContext()
+	ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "view=tree&depth=2"}}
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.GetCommentsForArticle(ginContext)
+
+	// Then
+	root := validComment(1)
+	root.Replies = []*models.Comment{validReply(2, 1)}
+	expected, _ := json.Marshal([]*models.Comment{root})
+	assert.Equal(t, string(expected), recorder.Body.String())
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestGetCommentsForArticleShouldReturn400ForInvalidView(t *testing.T) {
+	t.Run("Unknown view provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "view=graph"}}
+		ginContext.AddParam("id", "1")
+		routeHandler.GetCommentsForArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetCommentsForArticle with an unknown view it must return 400 error")
+	})
+	t.Run("Non-numeric depth provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "view=tree&depth=ABC"}}
+		ginContext.AddParam("id", "1")
+		routeHandler.GetCommentsForArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetCommentsForArticle with a non numeric depth it must return 400 error")
+	})
+	t.Run("Unknown sort provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "sort=popular"}}
+		ginContext.AddParam("id", "1")
+		routeHandler.GetCommentsForArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetCommentsForArticle with an unknown sort it must return 400 error")
+	})
+	t.Run("Negative limit provided", func(t *testing.T) {
+		defer initContext()
+		ginContext.Request = &http.Request{URL: &url.URL{RawQuery: "limit=-1"}}
+		ginContext.AddParam("id", "1")
+		routeHandler.GetCommentsForArticle(ginContext)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code, "When calling GetCommentsForArticle with a negative limit it must return 400 error")
+	})
+}
+
+func TestCreateCommentShouldReturn400ForInvalidParent(t *testing.T) {
+	// Given
+	defer initContext()
+
+	body, _ := json.Marshal(validReply(1, missingCommentId))
+	ginContext.Request = &http.Request{
+		URL:  &url.URL{},
+		Body: io.NopCloser(bytes.NewBuffer(body)),
+	}
+	ginContext.AddParam("id", "1")
+
+	// When
+	routeHandler.CreateComment(ginContext)
+
+	// Then
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	up := health.Check{Name: "database", Run: func(ctx context.Context) error { return nil }}
+	down := health.Check{Name: "migrations", Run: func(ctx context.Context) error { return errors.New("the database is dirty") }}
+	t.Run("Liveness doesn't run the checks", func(t *testing.T) {
+		defer initContext()
+		NewHealthHandler(health.NewChecker(down)).Liveness(ginContext)
+		assert.Equal(t, http.StatusOK, recorder.Code, "Liveness must return 200 even if a component is down")
+	})
+	t.Run("Readiness with all components up", func(t *testing.T) {
+		defer initContext()
+		NewHealthHandler(health.NewChecker(up)).Readiness(ginContext)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+	t.Run("Readiness with a component down", func(t *testing.T) {
+		defer initContext()
+		NewHealthHandler(health.NewChecker(up, down)).Readiness(ginContext)
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code, "Readiness must return 503 if any component is down")
+	})
+	t.Run("Status reports every component", func(t *testing.T) {
+		defer initContext()
+		NewHealthHandler(health.NewChecker(up, down)).Status(ginContext)
+		var report health.Report
+		json.Unmarshal(recorder.Body.Bytes(), &report)
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, health.StatusDown, report.Status)
+		assert.Len(t, report.Components, 2)
+		assert.Equal(t, "the database is dirty", report.Components[1].Error)
+	})
+}
+
 func initContext() {
 	recorder = httptest.NewRecorder()
-	context, _ = gin.CreateTestContext(recorder)
+	ginContext, _ = gin.CreateTestContext(recorder)
+	ginContext.Request = &http.Request{URL: &url.URL{}}
 }
 
-func (m *mockArticleService) GetArticleById(id int) (*models.Article, error) {
+func (m *mockArticleService) GetArticleById(ctx context.Context, id int) (*models.Article, error) {
+	if id == missingArticleId {
+		return nil, notFound(id)
+	}
+	if id == failingArticleId {
+		return nil, errors.New("connection reset")
+	}
 	return validArticle(id), nil
 }
 
-func (m *mockArticleService) GetArticles() ([]models.Article, error) {
-	return []models.Article{*validArticle(1), *validArticle(2)}, nil
+func (m *mockArticleService) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	if slug != articleSlug && slug != renamedSlug {
+		err := articles.ErrArticleNotFound.Wrap(repository.ErrRecordNotFound)
+		err.Message = "No article was found for slug: " + slug
+		return nil, err
+	}
+	return sluggedArticle(), nil
+}
+
+func (m *mockArticleService) GetArticles(ctx context.Context, limit int, cursor string, filter models.ArticleFilter) (*models.ArticlePage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.Filter = filter
+	if cursor != "" {
+		return nil, pagination.ErrInvalidCursor
+	}
+	return &models.ArticlePage{Articles: []models.Article{*validArticle(1), *validArticle(2)}, NextCursor: "next"}, nil
+}
+
+func (m *mockArticleService) Search(ctx context.Context, query string, limit int, cursor string) (*models.ArticleSearchPage, error) {
+	if query == "" {
+		return nil, articles.ErrEmptySearchQuery
+	}
+	return &models.ArticleSearchPage{Results: []models.ArticleSearchResult{
+		{Article: *validArticle(1), Rank: 0.5, Snippet: "<mark>Awesome</mark> article is <mark>awesome</mark>"},
+	}}, nil
 }
 
-func (m *mockArticleService) CreateArticle(article *models.Article) error {
+func (m *mockArticleService) CreateArticle(ctx context.Context, article *models.Article) error {
+	if err := validation.Validate(article); err != nil {
+		return err
+	}
 	m.CreateArticleCalled = true
+	article.Id = createdId
 	return nil
 }
 
+func (m *mockArticleService) UpdateArticle(ctx context.Context, article *models.Article) error {
+	if article.Id == missingArticleId {
+		return notFound(article.Id)
+	}
+	return nil
+}
+
+func (m *mockArticleService) PatchArticle(ctx context.Context, id int, patch *models.ArticlePatch) (*models.Article, error) {
+	if id == missingArticleId {
+		return nil, notFound(id)
+	}
+	article := validArticle(id)
+	if patch.Title != nil {
+		article.Title = *patch.Title
+	}
+	if patch.Content != nil {
+		article.Content = *patch.Content
+	}
+	return article, nil
+}
+
+func (m *mockArticleService) DeleteArticle(ctx context.Context, id int) error {
+	if id == missingArticleId {
+		return notFound(id)
+	}
+	m.DeleteArticleCalled = true
+	return nil
+}
+
+func (m *mockArticleService) ScheduleArticle(ctx context.Context, id int, schedule *models.ArticleSchedule) (*models.Article, error) {
+	if schedule.PublishAt == nil {
+		return nil, articles.ErrInvalidPublishAt
+	}
+	article, err := m.transition(id, models.StatusScheduled)
+	if err == nil {
+		article.PublishAt = schedule.PublishAt
+	}
+	return article, err
+}
+
+func (m *mockArticleService) PublishArticle(ctx context.Context, id int) (*models.Article, error) {
+	return m.transition(id, models.StatusPublished)
+}
+
+func (m *mockArticleService) UnpublishArticle(ctx context.Context, id int) (*models.Article, error) {
+	return m.transition(id, models.StatusDraft)
+}
+
+func (m *mockArticleService) ArchiveArticle(ctx context.Context, id int) (*models.Article, error) {
+	return m.transition(id, models.StatusArchived)
+}
+
+func (m *mockArticleService) transition(id int, status models.ArticleStatus) (*models.Article, error) {
+	switch id {
+	case missingArticleId:
+		return nil, notFound(id)
+	case archivedArticleId:
+		return nil, articles.ErrInvalidStatusTransition
+	}
+	article := validArticle(id)
+	article.Status = status
+	return article, nil
+}
+
+func (m *mockArticleService) GetTags(ctx context.Context) ([]models.TagCount, error) {
+	return []models.TagCount{{Name: "go", Count: 2}, {Name: "postgres", Count: 1}}, nil
+}
+
+func (m *mockArticleService) GetArticleRevisions(ctx context.Context, id int, limit int, cursor string) (*models.ArticleRevisionPage, error) {
+	if id == missingArticleId {
+		return nil, notFound(id)
+	}
+	return &models.ArticleRevisionPage{Revisions: []models.ArticleRevision{*validRevision(id, 2), *validRevision(id, 1)}}, nil
+}
+
+func (m *mockArticleService) GetArticleRevision(ctx context.Context, id int, revision int) (*models.ArticleRevision, error) {
+	if id == missingArticleId {
+		return nil, notFound(id)
+	}
+	if revision == missingRevision {
+		return nil, articles.ErrRevisionNotFound
+	}
+	return validRevision(id, revision), nil
+}
+
+func (m *mockArticleService) DiffArticleRevisions(ctx context.Context, id int, from int, to int) (string, error) {
+	if _, err := m.GetArticleRevision(ctx, id, from); err != nil {
+		return "", err
+	}
+	return "--- revisions/1\n+++ revisions/2\n@@ -1 +1 @@\n-Awesome\n+Awesome!\n", nil
+}
+
+func (m *mockArticleService) RestoreArticleRevision(ctx context.Context, id int, revision int) (*models.Article, error) {
+	restored, err := m.GetArticleRevision(ctx, id, revision)
+	if err != nil {
+		return nil, err
+	}
+	article := validArticle(id)
+	article.Title, article.Content = restored.Title, restored.Content
+	return article, nil
+}
+
 func (m *mockArticleService) Reset() {
 	m.CreateArticleCalled = false
+	m.DeleteArticleCalled = false
+	m.Filter = models.ArticleFilter{}
+}
+
+func (m *mockCommentService) GetCommentById(ctx context.Context, articleId int, id int) (*models.Comment, error) {
+	if id == missingCommentId {
+		return nil, comments.ErrCommentNotFound
+	}
+	return validComment(id), nil
 }
 
-func (m *mockCommentService) GetCommentsByArticleId(articleId int) ([]models.Comment, error) {
-	return []models.Comment{*validComment(1), *validComment(2)}, nil
+func (m *mockCommentService) GetComments(ctx context.Context, articleId int, sort models.CommentSort, limit int, cursor string) (*models.CommentPage, error) {
+	if articleId == missingArticleId {
+		return nil, notFound(articleId)
+	}
+	return &models.CommentPage{Comments: []models.Comment{*validComment(1), *validComment(2)}}, nil
+}
+
+func (m *mockCommentService) GetCommentTreeByArticleId(ctx context.Context, articleId int, depth int, sort models.CommentSort) ([]*models.Comment, error) {
+	if articleId == missingArticleId {
+		return nil, notFound(articleId)
+	}
+	root := validComment(1)
+	root.Replies = []*models.Comment{validReply(2, 1)}
+	return []*models.Comment{root}, nil
 }
 
-func (m *mockCommentService) CreateComment(article *models.Comment) error {
+func (m *mockCommentService) CreateComment(ctx context.Context, comment *models.Comment) error {
+	if comment.ParentId != nil && *comment.ParentId == missingCommentId {
+		return comments.ErrInvalidParentComment
+	}
 	m.CalledCreateComment = true
+	comment.Id = createdId
 	return nil
 }
 
@@ -200,10 +1134,33 @@ func (m *mockCommentService) Reset() {
 	m.CalledCreateComment = false
 }
 
+func notFound(id int) error {
+	err := articles.ErrArticleNotFound.Wrap(repository.ErrRecordNotFound)
+	err.Message = "No article was found for id: " + strconv.Itoa(id)
+	return err
+}
+
 func validArticle(id int) *models.Article {
 	return &models.Article{Id: id, Title: "Awesome", Content: "Awesome article is awesome", CreationTimestamp: time.UnixMilli(1733829984990)}
 }
 
+func sluggedArticle() *models.Article {
+	article := validArticle(1)
+	article.Slug = articleSlug
+	return article
+}
+
+func validRevision(articleId int, revision int) *models.ArticleRevision {
+	return &models.ArticleRevision{ArticleId: articleId, Revision: revision, Title: "Awesome", Content: "Revision " + strconv.Itoa(revision),
+		Tags: []string{}, Status: models.StatusDraft, CreationTimestamp: time.UnixMilli(1733829984990)}
+}
+
 func validComment(id int) *models.Comment {
 	return &models.Comment{Id: id, ArticleId: 1, Author: "Ahmed Ehab", Content: "I like this awesome project and article", CreationTimestamp: time.UnixMilli(1733829984990)}
 }
+
+func validReply(id int, parentId int) *models.Comment {
+	reply := validComment(id)
+	reply.ParentId = &parentId
+	return reply
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/health"
+	"github.com/gin-gonic/gin"
+)
+
+// checksTimeout keeps the probes fast even when the request timeout is longer, a slow dependency counts as down
+const checksTimeout = 2 * time.Second
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Liveness only tells that the process is serving requests, it doesn't touch any dependency
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readiness responds with 503 when any of the components is down so no traffic is routed to this instance
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.run(c)
+	c.JSON(reportStatus(report), gin.H{"status": report.Status})
+}
+
+// Status responds with the result and latency of every component check
+func (h *HealthHandler) Status(c *gin.Context) {
+	report := h.run(c)
+	c.JSON(reportStatus(report), report)
+}
+
+func (h *HealthHandler) run(c *gin.Context) health.Report {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checksTimeout)
+	defer cancel()
+	return h.checker.Run(ctx)
+}
+
+func reportStatus(report health.Report) int {
+	if report.Status == health.StatusUp {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
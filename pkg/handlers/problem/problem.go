@@ -0,0 +1,252 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is the non-standard status used when the client goes away before the response is ready
+const StatusClientClosedRequest = 499
+
+// ContentType is the media type of problem details responses as defined by RFC 7807
+const ContentType = "application/problem+json"
+
+// TypeUriPrefix is prepended to the problem code to build the problem type uri
+const TypeUriPrefix = "urn:go-articles-test:problem:"
+
+// Problem is an RFC 7807 problem details response body
+// Code is a stable machine-readable identifier of the problem, clients should rely on it rather than the title or detail
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a problem with a single field of the request body
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newProblem(status int, code string, detail string) *Problem {
+	title := http.StatusText(status)
+	if status == StatusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+	return &Problem{Type: TypeUriPrefix + code, Title: title, Status: status, Detail: detail, Code: code}
+}
+
+// Respond writes the problem as the response with its status, the instance is set to the request path
+func Respond(c *gin.Context, p *Problem) {
+	if c.Request != nil && c.Request.URL != nil {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ContentType)
+	c.JSON(p.Status, p)
+}
+
+// FromError builds the problem of a domain error with the status matching its kind
+// errors that aren't domain errors are responded to with the fallback problem
+// context errors are mapped to 504 if the request's deadline was exceeded or 499 if the client closed the request
+func FromError(err error, fallback *Problem) *Problem {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return RequestTimedOut()
+	case errors.Is(err, context.Canceled):
+		return RequestCanceled()
+	}
+	var domainErr *domainerr.Error
+	if !errors.As(err, &domainErr) {
+		return fallback
+	}
+	p := newProblem(Status(err), domainErr.Code, domainErr.Message)
+	for _, field := range domainErr.Fields {
+		p.Errors = append(p.Errors, FieldError{Field: field.Field, Code: field.Code, Message: field.Message})
+	}
+	return p
+}
+
+// Status maps the kind of a domain error to its http status, 500 for any other error
+// Validation errors that list invalid fields are mapped to 422 as the request body itself is well-formed
+func Status(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	}
+	var domainErr *domainerr.Error
+	if errors.As(err, &domainErr) && len(domainErr.Fields) > 0 {
+		return http.StatusUnprocessableEntity
+	}
+	switch {
+	case errors.Is(err, domainerr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domainerr.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domainerr.ErrValidation), errors.Is(err, domainerr.ErrForeignKey):
+		return http.StatusBadRequest
+	case errors.Is(err, domainerr.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// WithBindingError adds a field error to the problem if the binding error was caused by a specific field
+func (p *Problem) WithBindingError(err error) *Problem {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		p.Errors = append(p.Errors, FieldError{Field: typeErr.Field, Code: "invalid_type", Message: "must be of type " + typeErr.Type.String()})
+	}
+	return p
+}
+
+func RequestTimedOut() *Problem {
+	return newProblem(http.StatusGatewayTimeout, "request_timeout", "The request took too long to complete")
+}
+
+func RequestCanceled() *Problem {
+	return newProblem(StatusClientClosedRequest, "request_canceled", "The client closed the request before it was completed")
+}
+
+func Unauthorized() *Problem {
+	return newProblem(http.StatusUnauthorized, "unauthorized", "A valid editor token must be provided in the Authorization header")
+}
+
+func SchedulerRunFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "scheduler_run_failed", "An error occured while publishing the scheduled articles")
+}
+
+// Article problems start
+
+func InvalidArticleId() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_article_id", "Invalid or no article id was supplied")
+}
+
+func ArticleFetchFailed(id string) *Problem {
+	return newProblem(http.StatusInternalServerError, "article_fetch_failed", "Encountered an error while getting article by id: "+id)
+}
+
+func InvalidArticleSlug() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_article_slug", "No article slug was supplied")
+}
+
+func ArticleSlugFetchFailed(slug string) *Problem {
+	return newProblem(http.StatusInternalServerError, "article_slug_fetch_failed", "Encountered an error while getting article by slug: "+slug)
+}
+
+func ArticleListFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_list_failed", "An error occured while getting all articles")
+}
+
+func InvalidPagination() *Problem {
+	return newProblem(http.StatusBadRequest, pagination.InvalidPaginationCode, "Invalid limit or cursor was supplied")
+}
+
+func InvalidTagsMatch() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_tags_match", "Invalid match was provided for the tags, it must be either any or all")
+}
+
+func InvalidArticleStatus() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_article_status", "Invalid status was provided, it must be either draft, scheduled, published or archived")
+}
+
+func InvalidArticleSchedule(err error) *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_article_schedule", "An error occured while parsing the request body as a schedule").
+		WithBindingError(err)
+}
+
+func ArticleTransitionFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_transition_failed", "An error occured while changing the status of an article")
+}
+
+func TagListFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "tag_list_failed", "An error occured while getting all tags")
+}
+
+func ArticleSearchFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_search_failed", "An error occured while searching articles")
+}
+
+func InvalidArticleBody(err error) *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_article_body", "An error occured while parsing the request body as an article").
+		WithBindingError(err)
+}
+
+func ArticleCreationFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_creation_failed", "An error occured while creating an article")
+}
+
+func ArticleUpdateFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_update_failed", "An error occured while updating an article")
+}
+
+func ArticleDeletionFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "article_deletion_failed", "An error occured while deleting an article")
+}
+
+func InvalidRevision() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_revision", "Invalid or no revision was supplied")
+}
+
+func RevisionFetchFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "revision_fetch_failed", "An error occured while getting the revisions of an article")
+}
+
+func RevisionDiffFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "revision_diff_failed", "An error occured while comparing the revisions of an article")
+}
+
+func RevisionRestoreFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "revision_restore_failed", "An error occured while restoring a revision of an article")
+}
+
+// Article problems end
+
+// Comment problems start
+
+func InvalidCommentBody(err error) *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_comment_body", "An error occured while parsing the request body as a comment").
+		WithBindingError(err)
+}
+
+func InvalidCommentId() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_comment_id", "Invalid or no comment id was supplied")
+}
+
+func CommentFetchFailed(id string) *Problem {
+	return newProblem(http.StatusInternalServerError, "comment_fetch_failed", "Encountered an error while getting comment by id: "+id)
+}
+
+func CommentCreationFailed() *Problem {
+	return newProblem(http.StatusInternalServerError, "comment_creation_failed", "An error occured while creating a comment")
+}
+
+func InvalidCommentsView() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_comments_view", "Invalid view was provided for the comments, it must be either flat or tree")
+}
+
+func InvalidCommentsSort() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_comments_sort", "Invalid sort was provided for the comments, it must be either oldest or newest")
+}
+
+func InvalidCommentsDepth() *Problem {
+	return newProblem(http.StatusBadRequest, "invalid_comments_depth", "Invalid depth was provided for the comments tree")
+}
+
+func CommentListFailed(articleId string) *Problem {
+	return newProblem(http.StatusInternalServerError, "comment_list_failed", "An error occured while fetching comments for the articleId: "+articleId)
+}
+
+// Comment problems end
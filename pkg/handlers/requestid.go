@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+// maxRequestIdLength bounds the ids accepted from the clients so they can't flood the logs
+const maxRequestIdLength = 128
+
+// RequestId tags every request with the X-Request-ID header's value, or a new id if it's missing or invalid,
+// the id is echoed in the response and added with the trace id to the logger carried by the request's context
+// once the request is handled a line is logged with its outcome and latency
+func RequestId(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(RequestIdHeader)
+		if !validRequestId(id) {
+			id = newRequestId()
+		}
+		c.Header(RequestIdHeader, id)
+		requestLogger := logger.With("request_id", id)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.HasTraceID() {
+			requestLogger = requestLogger.With("trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), requestLogger))
+
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		requestLogger.With(logging.PackageKey, "http").Log(c.Request.Context(), level, "Request completed",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	}
+}
+
+func validRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for _, r := range id {
+		if r < '!' || r > '~' { // printable ASCII without spaces
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/handlers/problem"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/scheduler"
+	"github.com/gin-gonic/gin"
+)
+
+type SchedulerHandler struct {
+	scheduler *scheduler.Scheduler
+}
+
+func NewSchedulerHandler(scheduler *scheduler.Scheduler) *SchedulerHandler {
+	return &SchedulerHandler{scheduler: scheduler}
+}
+
+// State responds with the scheduler's state, e.g. when it last ran and what it published
+func (h *SchedulerHandler) State(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduler.State())
+}
+
+// Run publishes the due articles right away and responds with the run, a failed run is responded to with a problem
+func (h *SchedulerHandler) Run(c *gin.Context) {
+	run, err := h.scheduler.RunNow(c.Request.Context())
+	if err != nil {
+		respondError(c, err, problem.SchedulerRunFailed())
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
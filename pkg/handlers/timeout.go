@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const DefaultRequestTimeout = 10 * time.Second
+
+// RequestTimeout sets a deadline on the request's context that's passed down to the services and repositories,
+// operations still running once it fires are aborted and the request is responded to with 504
+func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
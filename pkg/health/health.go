@@ -0,0 +1,120 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check is a single component check, it must return once ctx is done
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+type ComponentReport struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the result of running all the checks, Status is only up if all the components are up
+type Report struct {
+	Status     string            `json:"status"`
+	Uptime     string            `json:"uptime"`
+	Components []ComponentReport `json:"components"`
+}
+
+type Checker struct {
+	checks    []Check
+	startedAt time.Time
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, startedAt: time.Now()}
+}
+
+// Run runs all the checks concurrently and reports them in the order they were provided
+func (checker *Checker) Run(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Uptime: time.Since(checker.startedAt).Round(time.Second).String()}
+	report.Components = make([]ComponentReport, len(checker.checks))
+	var wg sync.WaitGroup
+	for i, check := range checker.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Run(ctx)
+			component := ComponentReport{Name: check.Name, Status: StatusUp, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				component.Status, component.Error = StatusDown, err.Error()
+			}
+			report.Components[i] = component
+		}()
+	}
+	wg.Wait()
+	for _, component := range report.Components {
+		if component.Status == StatusDown {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+// DatabaseCheck checks that the database is reachable
+func DatabaseCheck(db *sql.DB) Check {
+	return Check{Name: "database", Run: db.PingContext}
+}
+
+// MigrationCheck checks that the database was migrated to the expected version and that the last migration didn't fail
+// It reads the schema_migrations table maintained by golang-migrate
+func MigrationCheck(db *sql.DB, expectedVersion uint) Check {
+	return Check{Name: "migrations", Run: func(ctx context.Context) error {
+		var version uint
+		var dirty bool
+		err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no migration was applied, expected version %d", expectedVersion)
+		}
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("migration %d failed and the database is dirty", version)
+		}
+		if version != expectedVersion {
+			return fmt.Errorf("the database is at version %d but version %d is expected", version, expectedVersion)
+		}
+		return nil
+	}}
+}
+
+// LatestMigrationVersion returns the version of the last migration in the source
+func LatestMigrationVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
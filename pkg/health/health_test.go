@@ -0,0 +1,46 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/db"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	// Given
+	checker := NewChecker(
+		Check{Name: "database", Run: func(ctx context.Context) error { return nil }},
+		Check{Name: "migrations", Run: func(ctx context.Context) error { return errors.New("the database is dirty") }},
+	)
+
+	// When
+	report := checker.Run(context.Background())
+
+	// Then
+	assert.Equal(t, StatusDown, report.Status, "The report must be down if any component is down")
+	require.Len(t, report.Components, 2)
+	assert.Equal(t, "database", report.Components[0].Name)
+	assert.Equal(t, StatusUp, report.Components[0].Status)
+	assert.Equal(t, StatusDown, report.Components[1].Status)
+	assert.Equal(t, "the database is dirty", report.Components[1].Error)
+}
+
+func TestRunWithoutChecks(t *testing.T) {
+	assert.Equal(t, StatusUp, NewChecker().Run(context.Background()).Status)
+}
+
+func TestLatestMigrationVersion(t *testing.T) {
+	src, err := iofs.New(db.Migrations, ".")
+	require.NoError(t, err)
+	defer src.Close()
+
+	version, err := LatestMigrationVersion(src)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(10), version)
+}
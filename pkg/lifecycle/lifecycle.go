@@ -0,0 +1,51 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Lifecycle collects the shutdown hooks of the application's components
+// Hooks run in the reverse order of their registration, like deferred calls, so a component
+// registered after its dependencies (e.g. a background worker using the database pool) is stopped before them
+type Lifecycle struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// OnShutdown registers a hook to be run on shutdown, the hook must return once ctx is done
+func (lc *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.hooks = append(lc.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown runs all the hooks in reverse order, a failing hook doesn't prevent the following ones from running
+// and all the failures are returned joined together
+func (lc *Lifecycle) Shutdown(ctx context.Context) error {
+	lc.mu.Lock()
+	hooks := lc.hooks
+	lc.hooks = nil
+	lc.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		slog.Info("Shutting down", "component", hooks[i].name)
+		if err := hooks[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutting down %s: %w", hooks[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
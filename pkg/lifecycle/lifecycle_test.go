@@ -0,0 +1,36 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownRunsHooksInReverseOrder(t *testing.T) {
+	// Given
+	lc := New()
+	order := []string{}
+	lc.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	lc.OnShutdown("worker", func(ctx context.Context) error {
+		order = append(order, "worker")
+		return errors.New("worker failed")
+	})
+	lc.OnShutdown("server", func(ctx context.Context) error {
+		order = append(order, "server")
+		return nil
+	})
+
+	// When
+	err := lc.Shutdown(context.Background())
+
+	// Then
+	assert.Equal(t, []string{"server", "worker", "database"}, order, "A failing hook must not stop the following ones")
+	assert.EqualError(t, err, "shutting down worker: worker failed")
+	assert.NoError(t, lc.Shutdown(context.Background()), "Hooks must only run once")
+	assert.Len(t, order, 3)
+}
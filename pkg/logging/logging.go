@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// PackageKey is the attribute that tells which package a log line comes from, the per-package levels are matched against it
+const PackageKey = "package"
+
+type contextKey struct{}
+
+// Levels holds the minimum level of each package, packages without their own level use Default
+type Levels struct {
+	Default  slog.Level
+	Packages map[string]slog.Level
+}
+
+// ParseLevels parses a levels spec like "info,repository=debug,handlers=warn"
+// the entry without a package is the default level, info if it's omitted
+func ParseLevels(spec string) (Levels, error) {
+	levels := Levels{Default: slog.LevelInfo, Packages: map[string]slog.Level{}}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		pkg, levelName, hasPackage := strings.Cut(entry, "=")
+		if !hasPackage {
+			pkg, levelName = "", entry
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(levelName)); err != nil {
+			return Levels{}, fmt.Errorf("invalid log level [%s]: %w", entry, err)
+		}
+		if hasPackage {
+			levels.Packages[strings.TrimSpace(pkg)] = level
+		} else {
+			levels.Default = level
+		}
+	}
+	return levels, nil
+}
+
+// New creates a JSON logger writing to w that filters every line by the level of its package
+func New(w io.Writer, levels Levels) *slog.Logger {
+	// The JSON handler lets everything through, the levels are enforced by the levelHandler
+	json := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.Level(-8)})
+	return slog.New(&levelHandler{next: json, levels: levels, level: levels.Default})
+}
+
+// WithLogger returns a copy of ctx carrying logger, it's picked up by FromContext down the call chain
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx (e.g. with the request id) or the default one
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// For returns the logger carried by ctx for the given package, its lines are filtered by the package's level
+func For(ctx context.Context, pkg string) *slog.Logger {
+	return FromContext(ctx).With(PackageKey, pkg)
+}
+
+// levelHandler decides the minimum level once the package attribute is added to the logger
+type levelHandler struct {
+	next   slog.Handler
+	levels Levels
+	level  slog.Level
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	level := h.level
+	for _, attr := range attrs {
+		if attr.Key != PackageKey {
+			continue
+		}
+		if packageLevel, ok := h.levels.Packages[attr.Value.String()]; ok {
+			level = packageLevel
+		}
+	}
+	return &levelHandler{next: h.next.WithAttrs(attrs), levels: h.levels, level: level}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{next: h.next.WithGroup(name), levels: h.levels, level: h.level}
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLevels(t *testing.T) {
+	t.Run("Default and package levels", func(t *testing.T) {
+		levels, err := ParseLevels("warn, repository=debug,handlers=error")
+		require.NoError(t, err)
+		assert.Equal(t, slog.LevelWarn, levels.Default)
+		assert.Equal(t, map[string]slog.Level{"repository": slog.LevelDebug, "handlers": slog.LevelError}, levels.Packages)
+	})
+	t.Run("Empty spec", func(t *testing.T) {
+		levels, err := ParseLevels("")
+		require.NoError(t, err)
+		assert.Equal(t, slog.LevelInfo, levels.Default, "The default level must be info if it's not provided")
+	})
+	t.Run("Invalid level", func(t *testing.T) {
+		_, err := ParseLevels("repository=verbose")
+		assert.Error(t, err)
+	})
+}
+
+func TestPackageLevels(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+	logger := New(&out, Levels{Default: slog.LevelInfo, Packages: map[string]slog.Level{"repository": slog.LevelDebug, "handlers": slog.LevelWarn}})
+	ctx := WithLogger(context.Background(), logger.With("request_id", "abc"))
+
+	// When
+	For(ctx, "repository").Debug("repository debug")
+	For(ctx, "handlers").Info("handlers info")
+	For(ctx, "articles").Debug("articles debug")
+	For(ctx, "articles").Info("articles info")
+
+	// Then
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2, "Only the lines at or above their package's level must be logged")
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "repository debug", first["msg"])
+	assert.Equal(t, "abc", first["request_id"], "The logger carried in the context must be used")
+	assert.Equal(t, "repository", first[PackageKey])
+	assert.Contains(t, lines[1], "articles info")
+}
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "articles"
+
+// unmatchedRoute labels the requests that didn't match any route, so random paths don't blow up the label cardinality
+const unmatchedRoute = "unmatched"
+
+// Registry holds all the app's metrics, it's used instead of the global registry to keep them in one place
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status code",
+	}, []string{"method", "route", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method and route",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// ArticlesCreated counts the articles created successfully
+	ArticlesCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "articles_created_total",
+		Help:      "Number of articles created",
+	})
+	// CommentsCreated counts the comments created successfully
+	CommentsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "comments_created_total",
+		Help:      "Number of comments created",
+	})
+	// ArticlesPublishedByScheduler counts the scheduled articles published once they were due
+	ArticlesPublishedByScheduler = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_articles_published_total",
+		Help:      "Number of scheduled articles published by the scheduler",
+	})
+	// SchedulerRuns counts the scheduler runs by their trigger (interval or manual) and result (success or failure)
+	SchedulerRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_runs_total",
+		Help:      "Number of scheduler runs by trigger and result",
+	}, []string{"trigger", "result"})
+	// SchedulerLastSuccess is the unix time of the last successful scheduler run, alerts can fire when it's too old
+	SchedulerLastSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful scheduler run",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		ArticlesCreated,
+		CommentsCreated,
+		ArticlesPublishedByScheduler,
+		SchedulerRuns,
+		SchedulerLastSuccess,
+	)
+}
+
+// Middleware records the count and latency of every request labeled by its route template (e.g. /v1/articles/:id)
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		requestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDB exposes the connection pool stats (open, in use and idle connections, waits, etc.) of the database
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
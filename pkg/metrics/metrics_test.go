@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	// Given
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	engine.GET("/v1/articles/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// When
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/articles/1", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/articles/2", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/random", nil))
+
+	// Then
+	assert.Equal(t, 2.0, testutil.ToFloat64(requestsTotal.WithLabelValues(http.MethodGet, "/v1/articles/:id", "200")),
+		"Requests must be labeled by the route template and not the actual path")
+	assert.Equal(t, 1.0, testutil.ToFloat64(requestsTotal.WithLabelValues(http.MethodGet, unmatchedRoute, "404")))
+}
+
+func TestHandler(t *testing.T) {
+	// Given
+	ArticlesCreated.Inc()
+	recorder := httptest.NewRecorder()
+
+	// When
+	Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Then
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.True(t, strings.Contains(recorder.Body.String(), "articles_articles_created_total 1"), "The domain counters must be exposed")
+}
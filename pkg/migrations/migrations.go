@@ -0,0 +1,223 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/db"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+const (
+	Postgres = "postgres"
+	Sqlite   = "sqlite"
+)
+
+// Migrator applies the migrations of a storage to its database
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// Source returns the migrations of the storage embedded in the binary, or the ones in dir if it's provided
+// dir is meant to hotfix the migrations without a new build, it must hold the storage's migrations directly
+func Source(storage string, dir string) (source.Driver, error) {
+	if dir != "" {
+		return source.Open("file://" + filepath.ToSlash(dir))
+	}
+	path := "."
+	if storage == Sqlite {
+		path = "sqlite"
+	}
+	return iofs.New(db.Migrations, path)
+}
+
+// New creates a migrator for the storage's database, either postgres or sqlite, with the migrations from Source
+// it holds a connection of the db until it's closed but never closes the db itself
+func New(ctx context.Context, instance *sql.DB, storage string, dir string) (*Migrator, error) {
+	var driver database.Driver
+	switch storage {
+	case Postgres:
+		conn, err := instance.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// Unlike WithInstance, a driver on a single connection closes only that connection
+		if driver, err = postgres.WithConnection(ctx, conn, &postgres.Config{}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	case Sqlite:
+		sqliteDriver, err := sqlite.WithInstance(instance, &sqlite.Config{})
+		if err != nil {
+			return nil, err
+		}
+		driver = keepOpen{sqliteDriver}
+	default:
+		return nil, fmt.Errorf("the %s storage has no migrations", storage)
+	}
+	src, err := Source(storage, dir)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	m, err := migrate.NewWithInstance("migrations", src, "articles", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return nil, err
+	}
+	m.Log = logger{logging.For(ctx, "migrations")}
+	return &Migrator{m: m}, nil
+}
+
+// Up applies all the pending migrations, it's a no-op if there are none
+func (migrator *Migrator) Up() error {
+	if err := migrator.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the last n applied migrations
+func (migrator *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("the number of migrations to revert must be positive, the provided value was [%d]", n)
+	}
+	return migrator.m.Steps(-n)
+}
+
+// Goto migrates up or down to the given version
+func (migrator *Migrator) Goto(version uint) error {
+	if err := migrator.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Version returns the current version, 0 if no migration was applied, dirty is true if the last one failed midway
+func (migrator *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = migrator.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Force sets the version without running any migration and clears the dirty flag, it's meant to recover
+// from a failed migration once the database was fixed manually
+func (migrator *Migrator) Force(version uint) error {
+	return migrator.m.Force(int(version))
+}
+
+// Close releases the connection held by the migrator
+func (migrator *Migrator) Close() error {
+	sourceErr, databaseErr := migrator.m.Close()
+	return errors.Join(sourceErr, databaseErr)
+}
+
+// Command runs a migrate subcommand, either up, down N, goto V, version or force V, and writes its outcome to out
+func Command(migrator *Migrator, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("a migrate command is required, either up, down N, goto V, version or force V")
+	}
+	command, args := args[0], args[1:]
+	var err error
+	switch command {
+	case "up":
+		err = expectArgs(command, args, 0)
+		if err == nil {
+			err = migrator.Up()
+		}
+	case "down":
+		var n uint
+		if n, err = numberArg(command, args); err == nil {
+			err = migrator.Down(int(n))
+		}
+	case "goto":
+		var version uint
+		if version, err = numberArg(command, args); err == nil {
+			err = migrator.Goto(version)
+		}
+	case "force":
+		var version uint
+		if version, err = numberArg(command, args); err == nil {
+			err = migrator.Force(version)
+		}
+	case "version":
+		err = expectArgs(command, args, 0)
+	default:
+		return fmt.Errorf("unknown migrate command [%s], it must be either up, down N, goto V, version or force V", command)
+	}
+	if err != nil {
+		return err
+	}
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Fprintf(out, "version %d (dirty)\n", version)
+	} else {
+		fmt.Fprintf(out, "version %d\n", version)
+	}
+	return nil
+}
+
+func expectArgs(command string, args []string, count int) error {
+	if len(args) != count {
+		return fmt.Errorf("migrate %s expects %d argument(s), got [%s]", command, count, strings.Join(args, " "))
+	}
+	return nil
+}
+
+func numberArg(command string, args []string) (uint, error) {
+	if err := expectArgs(command, args, 1); err != nil {
+		return 0, err
+	}
+	number, err := strconv.ParseUint(args[0], 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("migrate %s expects a positive number, got [%s]", command, args[0])
+	}
+	return uint(number), nil
+}
+
+// keepOpen prevents the migrator from closing the db shared with the repository
+type keepOpen struct {
+	database.Driver
+}
+
+func (keepOpen) Close() error {
+	return nil
+}
+
+// logger logs the migrations applied by migrate
+type logger struct {
+	logger *slog.Logger
+}
+
+func (l logger) Printf(format string, v ...any) {
+	message := strings.TrimSpace(fmt.Sprintf(format, v...))
+	if strings.HasPrefix(message, "error: ") {
+		l.logger.Error(strings.TrimPrefix(message, "error: "))
+		return
+	}
+	l.logger.Info(message)
+}
+
+func (l logger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,114 @@
+package migrations
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const latestVersion = 10
+
+func TestUpAndDown(t *testing.T) {
+	// Given
+	db, migrator := newSqliteMigrator(t)
+
+	// When
+	require.NoError(t, migrator.Up())
+	require.NoError(t, migrator.Up(), "Up must be a no-op when there's no pending migration")
+
+	// Then
+	assertVersion(t, migrator, latestVersion)
+	_, err := db.Exec("INSERT INTO article(title, content, creation_timestamp) VALUES ('title', 'content', CURRENT_TIMESTAMP)")
+	require.NoError(t, err)
+
+	t.Run("Down reverts the last migrations", func(t *testing.T) {
+		require.NoError(t, migrator.Down(latestVersion-2))
+		assertVersion(t, migrator, 2)
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'article_fts'").Scan(&count))
+		assert.Zero(t, count, "The search table must be dropped by the down migration")
+	})
+	t.Run("Down to the first migration drops everything", func(t *testing.T) {
+		require.NoError(t, migrator.Down(2))
+		assertVersion(t, migrator, 0)
+		var count int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name IN ('article', 'comment')").Scan(&count))
+		assert.Zero(t, count)
+	})
+	t.Run("Goto migrates up to the version", func(t *testing.T) {
+		require.NoError(t, migrator.Goto(3))
+		assertVersion(t, migrator, 3)
+	})
+	t.Run("Down with a non-positive number", func(t *testing.T) {
+		assert.Error(t, migrator.Down(0))
+	})
+}
+
+func TestCommand(t *testing.T) {
+	_, migrator := newSqliteMigrator(t)
+	var out bytes.Buffer
+	t.Run("Commands print the resulting version", func(t *testing.T) {
+		for _, args := range [][]string{{"up"}, {"down", "1"}, {"goto", "2"}, {"force", "4"}, {"version"}} {
+			out.Reset()
+			require.NoError(t, Command(migrator, args, &out), "migrate %v must succeed", args)
+		}
+		assert.Equal(t, "version 4\n", out.String())
+	})
+	t.Run("Invalid commands", func(t *testing.T) {
+		for _, args := range [][]string{nil, {"sideways"}, {"down"}, {"down", "-1"}, {"goto", "x"}, {"up", "2"}} {
+			assert.Error(t, Command(migrator, args, &out), "migrate %v must fail", args)
+		}
+	})
+}
+
+func TestSource(t *testing.T) {
+	t.Run("Embedded migrations", func(t *testing.T) {
+		for _, storage := range []string{Postgres, Sqlite} {
+			src, err := Source(storage, "")
+			require.NoError(t, err)
+			version, err := src.First()
+			assert.NoError(t, err)
+			assert.Equal(t, uint(1), version, "The %s migrations must be embedded", storage)
+			src.Close()
+		}
+	})
+	t.Run("Directory override", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "7_hotfix.up.sql"), []byte("SELECT 1;"), 0o600))
+		src, err := Source(Sqlite, dir)
+		require.NoError(t, err)
+		defer src.Close()
+		version, err := src.First()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), version, "The migrations must be read from the directory instead of the embedded ones")
+	})
+}
+
+func TestNewWithoutMigrations(t *testing.T) {
+	_, err := New(context.Background(), nil, "memory", "")
+	assert.Error(t, err)
+}
+
+func newSqliteMigrator(t *testing.T) (*sql.DB, *Migrator) {
+	db, err := sql.Open("sqlite", repository.SqliteDsn(filepath.Join(t.TempDir(), "articles.db")))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := New(context.Background(), db, Sqlite, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { migrator.Close() })
+	return db, migrator
+}
+
+func assertVersion(t *testing.T, migrator *Migrator, expected uint) {
+	version, dirty, err := migrator.Version()
+	require.NoError(t, err)
+	assert.Equal(t, expected, version)
+	assert.False(t, dirty)
+}
@@ -2,17 +2,120 @@ package models
 
 import "time"
 
+// The validate tags hold the validation rules of the models, see the validation package for the supported rules
+
+// Article is only visible to anonymous readers once it's published, its Status and PublishAt are changed through
+// the article service's transitions and are ignored when an article is created or updated
+// PublishAt is only set while the article is scheduled, it's when the scheduler publishes it
+// Slug is made out of the title by the article service, its previous slugs redirect to it
 type Article struct {
-	Id                int       `json:"id"`
-	Title             string    `json:"title"`
-	Content           string    `json:"content"`
-	CreationTimestamp time.Time `json:"creation_timestamp"`
+	Id                int           `json:"id"`
+	Slug              string        `json:"slug"`
+	Title             string        `json:"title" validate:"trim,required,max=255,singleline"`
+	Content           string        `json:"content" validate:"trim,required,max=65536,multiline"`
+	Tags              []string      `json:"tags" validate:"max=20,dive,required,max=50,tag"`
+	Status            ArticleStatus `json:"status"`
+	PublishAt         *time.Time    `json:"publish_at,omitempty"`
+	CreationTimestamp time.Time     `json:"creation_timestamp"`
+}
+
+type ArticleStatus string
+
+const (
+	StatusDraft     ArticleStatus = "draft"
+	StatusScheduled ArticleStatus = "scheduled"
+	StatusPublished ArticleStatus = "published"
+	StatusArchived  ArticleStatus = "archived"
+)
+
+// ArticleStatuses holds all the valid statuses
+var ArticleStatuses = []ArticleStatus{StatusDraft, StatusScheduled, StatusPublished, StatusArchived}
+
+// ArticleSchedule is the request body of scheduling an article
+type ArticleSchedule struct {
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// ArticlePage is a single page of articles, NextCursor is empty when there are no more pages
+type ArticlePage struct {
+	Articles   []Article `json:"articles"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// ArticleRevision is a snapshot of an article recorded every time it's created, updated or changes status
+// revisions are numbered from 1 for every article in the order they're recorded
+type ArticleRevision struct {
+	ArticleId         int           `json:"article_id"`
+	Revision          int           `json:"revision"`
+	Title             string        `json:"title"`
+	Content           string        `json:"content"`
+	Tags              []string      `json:"tags"`
+	Status            ArticleStatus `json:"status"`
+	CreationTimestamp time.Time     `json:"creation_timestamp"`
+}
+
+// ArticleRevisionPage is a single page of revisions, the latest first, NextCursor is empty when there are no more pages
+type ArticleRevisionPage struct {
+	Revisions  []ArticleRevision `json:"revisions"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// ArticleSearchResult is an article matching a search query, Snippet is an excerpt of
+// the content with the matching terms wrapped in <mark> tags
+type ArticleSearchResult struct {
+	Article
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type ArticleSearchPage struct {
+	Results    []ArticleSearchResult `json:"results"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// ArticlePatch holds the fields of a partial article update, nil fields are left untouched
+type ArticlePatch struct {
+	Title   *string   `json:"title"`
+	Content *string   `json:"content"`
+	Tags    *[]string `json:"tags"`
+}
+
+// ArticleFilter selects the articles having all the tags when MatchAllTags is set or any of them otherwise,
+// and having the status if it's set, an empty filter selects all the articles
+type ArticleFilter struct {
+	Tags         []string
+	MatchAllTags bool
+	Status       ArticleStatus
 }
 
+// TagCount is a tag with the number of articles having it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Comment is either a top-level comment on an article or a reply to another comment when ParentId is set
+// Replies is only filled when comments are fetched as a tree
 type Comment struct {
-	Id                int       `json:"id"`
-	ArticleId         int       `json:"article_id"`
-	Author            string    `json:"author"`
-	Content           string    `json:"content"`
-	CreationTimestamp time.Time `json:"creation_timestamp"`
+	Id                int        `json:"id"`
+	ArticleId         int        `json:"article_id"`
+	ParentId          *int       `json:"parent_id"`
+	Author            string     `json:"author" validate:"trim,required,max=255,singleline"`
+	Content           string     `json:"content" validate:"trim,required,max=65536,multiline"`
+	CreationTimestamp time.Time  `json:"creation_timestamp"`
+	Replies           []*Comment `json:"replies,omitempty"`
+}
+
+// CommentPage is a single page of comments, NextCursor is empty when there are no more pages
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
+
+// CommentSort is the order comments are listed in, by their creation_timestamp then id
+type CommentSort string
+
+const (
+	CommentSortOldest CommentSort = "oldest"
+	CommentSortNewest CommentSort = "newest"
+)
@@ -0,0 +1,89 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+)
+
+const InvalidPaginationCode = "invalid_pagination"
+
+var ErrInvalidCursor = domainerr.Validation(InvalidPaginationCode, "the provided cursor is invalid")
+
+const (
+	DefaultPageSize    = 20
+	DefaultMaxPageSize = 100
+)
+
+// Cursor points at the last item of a page, the next page starts right after it
+// Items are ordered by their timestamp first and their id second to keep the ordering stable
+type Cursor struct {
+	Timestamp time.Time
+	Id        int
+}
+
+// Encode returns an opaque url-safe representation of the cursor, it's meant to be passed back as is by clients
+func (c *Cursor) Encode() string {
+	raw := strconv.FormatInt(c.Timestamp.UnixMicro(), 10) + ":" + strconv.Itoa(c.Id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// Decode parses a cursor created by Encode, an empty string means no cursor and returns nil
+func Decode(encoded string) (*Cursor, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	timestampPart, idPart, found := strings.Cut(string(raw), ":")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+	micros, err := strconv.ParseInt(timestampPart, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Timestamp: time.UnixMicro(micros).UTC(), Id: id}, nil
+}
+
+// EncodeOffset returns an opaque cursor for result sets that can't be ordered by timestamp and id like ranked results
+func EncodeOffset(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// DecodeOffset parses a cursor created by EncodeOffset, an empty string means the first page
+func DecodeOffset(encoded string) (int, error) {
+	if encoded == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// Limit returns the page size to use for the requested limit, 0 means the default page size
+// and anything above the max page size is capped to it
+func Limit(requested int, maxPageSize int) int {
+	if maxPageSize <= 0 {
+		maxPageSize = DefaultMaxPageSize
+	}
+	if requested <= 0 {
+		requested = DefaultPageSize
+	}
+	return min(requested, maxPageSize)
+}
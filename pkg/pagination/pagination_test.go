@@ -0,0 +1,49 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	// Given
+	cursor := &Cursor{Timestamp: time.UnixMicro(1733829984990123).UTC(), Id: 42}
+
+	// When
+	decoded, err := Decode(cursor.Encode())
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+}
+
+func TestDecodeEmptyCursor(t *testing.T) {
+	decoded, err := Decode("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded, "An empty cursor means the first page")
+}
+
+func TestDecodeShouldFailForInvalidCursor(t *testing.T) {
+	for _, encoded := range []string{"!!!", "bm8tc2VwYXJhdG9y", "YWJjOjE", "MTIzOmFiYw"} {
+		_, err := Decode(encoded)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "Decoding [%s] must fail", encoded)
+	}
+}
+
+func TestOffsetRoundTrip(t *testing.T) {
+	offset, err := DecodeOffset(EncodeOffset(40))
+	assert.NoError(t, err)
+	assert.Equal(t, 40, offset)
+
+	_, err = DecodeOffset(EncodeOffset(-1))
+	assert.ErrorIs(t, err, ErrInvalidCursor, "Negative offsets must be rejected")
+}
+
+func TestLimit(t *testing.T) {
+	assert.Equal(t, DefaultPageSize, Limit(0, 50), "No limit should use the default page size")
+	assert.Equal(t, 10, Limit(10, 50))
+	assert.Equal(t, 50, Limit(500, 50), "Limit must be capped to the max page size")
+	assert.Equal(t, DefaultMaxPageSize, Limit(500, 0), "A non-positive max page size should use the default max")
+}
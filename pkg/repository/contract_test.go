@@ -0,0 +1,629 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * The contract every Storage implementation must satisfy, each implementation runs it
+ * with a function that returns a new empty storage for every test
+ */
+
+var ctx = context.Background()
+
+func runContractTests(t *testing.T, newStorage func(t *testing.T) Storage) {
+	t.Run("Article CRUD", func(t *testing.T) { testArticleCrud(t, newStorage(t)) })
+	t.Run("Missing articles", func(t *testing.T) { testMissingArticles(t, newStorage(t)) })
+	t.Run("Articles pagination", func(t *testing.T) { testArticlesPagination(t, newStorage(t)) })
+	t.Run("Pagination with default timestamps", func(t *testing.T) { testPaginationWithDefaultTimestamps(t, newStorage(t)) })
+	t.Run("Articles search", func(t *testing.T) { testArticlesSearch(t, newStorage(t)) })
+	t.Run("Article tags", func(t *testing.T) { testArticleTags(t, newStorage(t)) })
+	t.Run("Articles tags filter", func(t *testing.T) { testArticlesTagsFilter(t, newStorage(t)) })
+	t.Run("Article status", func(t *testing.T) { testArticleStatus(t, newStorage(t)) })
+	t.Run("Article publish_at on creation", func(t *testing.T) { testArticlePublishAtOnCreation(t, newStorage(t)) })
+	t.Run("Articles status filter", func(t *testing.T) { testArticlesStatusFilter(t, newStorage(t)) })
+	t.Run("Publishing due articles", func(t *testing.T) { testPublishDueArticles(t, newStorage(t)) })
+	t.Run("Article revisions", func(t *testing.T) { testArticleRevisions(t, newStorage(t)) })
+	t.Run("Article slugs", func(t *testing.T) { testArticleSlugs(t, newStorage(t)) })
+	t.Run("Comments", func(t *testing.T) { testComments(t, newStorage(t)) })
+	t.Run("Comments foreign keys", func(t *testing.T) { testCommentsForeignKeys(t, newStorage(t)) })
+	t.Run("Comments scoped to their article", func(t *testing.T) { testCommentsScopedToArticle(t, newStorage(t)) })
+	t.Run("Comments pagination", func(t *testing.T) { testCommentsPagination(t, newStorage(t)) })
+	t.Run("Canceled context", func(t *testing.T) { testCanceledContext(t, newStorage(t)) })
+}
+
+func testArticleCrud(t *testing.T, repo Storage) {
+	// Create
+	created := createArticle(t, repo, "Awesome Go", 0)
+
+	// Read
+	article, err := repo.GetArticleById(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Awesome Go", article.Title)
+	assert.True(t, created.CreationTimestamp.Equal(article.CreationTimestamp))
+
+	// Update
+	update := &models.Article{Id: created.Id, Title: "Awesome Go!", Content: "Updated"}
+	require.NoError(t, repo.UpdateArticle(ctx, update))
+	assert.True(t, created.CreationTimestamp.Equal(update.CreationTimestamp), "Updating must keep the creation timestamp")
+	article, err = repo.GetArticleById(ctx, created.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Awesome Go!", article.Title)
+	assert.Equal(t, "Updated", article.Content)
+
+	// Delete
+	require.NoError(t, repo.DeleteArticle(ctx, created.Id))
+	_, err = repo.GetArticleById(ctx, created.Id)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func testMissingArticles(t *testing.T, repo Storage) {
+	_, err := repo.GetArticleById(ctx, 404)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	assert.ErrorIs(t, repo.UpdateArticle(ctx, &models.Article{Id: 404, Title: "Title", Content: "Content"}), ErrRecordNotFound)
+	assert.ErrorIs(t, repo.DeleteArticle(ctx, 404), ErrRecordNotFound)
+}
+
+func testArticlesPagination(t *testing.T, repo Storage) {
+	// Given articles created in a different order than their timestamps
+	third := createArticle(t, repo, "Third", 3)
+	first := createArticle(t, repo, "First", 1)
+	second := createArticle(t, repo, "Second", 2)
+
+	// When
+	firstPage, err := repo.GetArticles(ctx, 2, nil, models.ArticleFilter{})
+	require.NoError(t, err)
+	last := firstPage[len(firstPage)-1]
+	secondPage, err := repo.GetArticles(ctx, 2, &pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id}, models.ArticleFilter{})
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, []int{first.Id, second.Id}, articleIds(firstPage))
+	assert.Equal(t, []int{third.Id}, articleIds(secondPage))
+}
+
+func testPaginationWithDefaultTimestamps(t *testing.T, repo Storage) {
+	// Given articles and comments timestamped on creation
+	articleIdsCreated, commentIdsCreated := []int{}, []int{}
+	for range 5 {
+		article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusPublished}
+		require.NoError(t, repo.CreateArticle(ctx, article))
+		articleIdsCreated = append(articleIdsCreated, article.Id)
+		comment := &models.Comment{ArticleId: articleIdsCreated[0], Author: "Ahmed Ehab", Content: "Awesome"}
+		require.NoError(t, repo.CreateComment(ctx, comment))
+		commentIdsCreated = append(commentIdsCreated, comment.Id)
+	}
+
+	// When paginating with cursors going through their encoded form like the API does
+	listed, cursor := []int{}, (*pagination.Cursor)(nil)
+	for page := 0; page < 5; page++ {
+		articles, err := repo.GetArticles(ctx, 2, cursor, models.ArticleFilter{})
+		require.NoError(t, err)
+		if len(articles) == 0 {
+			break
+		}
+		listed = append(listed, articleIds(articles)...)
+		last := articles[len(articles)-1]
+		cursor = roundTrip(t, &pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id})
+	}
+	listedComments, cursor := []int{}, (*pagination.Cursor)(nil)
+	for page := 0; page < 5; page++ {
+		comments, err := repo.GetCommentsPage(ctx, articleIdsCreated[0], models.CommentSortOldest, 2, cursor)
+		require.NoError(t, err)
+		if len(comments) == 0 {
+			break
+		}
+		listedComments = append(listedComments, commentIds(comments)...)
+		last := comments[len(comments)-1]
+		cursor = roundTrip(t, &pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id})
+	}
+
+	// Then every row is listed exactly once
+	assert.Equal(t, articleIdsCreated, listed)
+	assert.Equal(t, commentIdsCreated, listedComments)
+}
+
+func testArticlesSearch(t *testing.T, repo Storage) {
+	// Given
+	inTitle := createArticle(t, repo, "Gophers", 1)
+	createArticle(t, repo, "Unrelated", 2)
+	inContent := createArticle(t, repo, "Mascots", 3)
+	inContent.Content = "All about gophers and their burrows"
+	require.NoError(t, repo.UpdateArticle(ctx, inContent))
+
+	// When
+	results, err := repo.SearchArticles(ctx, "gophers", "", 10, 0)
+	require.NoError(t, err)
+
+	// Then
+	ids := []int{}
+	for _, result := range results {
+		ids = append(ids, result.Id)
+	}
+	assert.Equal(t, []int{inTitle.Id, inContent.Id}, ids, "Title matches must rank higher than content matches")
+	assert.Contains(t, results[1].Snippet, "<mark>gophers</mark>")
+}
+
+func testArticleTags(t *testing.T, repo Storage) {
+	// Given
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Tags: []string{"go", "awesome"}, Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(ctx, article))
+
+	// Then the tags are stored sorted
+	stored, err := repo.GetArticleById(ctx, article.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"awesome", "go"}, stored.Tags)
+
+	// Updating replaces the tags
+	article.Tags = []string{"go", "lists"}
+	require.NoError(t, repo.UpdateArticle(ctx, article))
+	stored, err = repo.GetArticleById(ctx, article.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"go", "lists"}, stored.Tags)
+	results, err := repo.SearchArticles(ctx, "awesome", "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{"go", "lists"}, results[0].Tags)
+
+	// Untagged articles have no tags
+	untagged := createArticle(t, repo, "Untagged", 0)
+	stored, err = repo.GetArticleById(ctx, untagged.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, stored.Tags)
+
+	// Tags are counted per article and unused tags aren't listed
+	other := &models.Article{Title: "Other", Content: "Other", Tags: []string{"go"}, Status: models.StatusPublished}
+	require.NoError(t, repo.CreateArticle(ctx, other))
+	tags, err := repo.GetTags(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "go", Count: 2}, {Name: "lists", Count: 1}}, tags)
+
+	// Deleting an article removes its tags
+	require.NoError(t, repo.DeleteArticle(ctx, article.Id))
+	tags, err = repo.GetTags(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TagCount{{Name: "go", Count: 1}}, tags)
+}
+
+func testArticlesTagsFilter(t *testing.T, repo Storage) {
+	// Given
+	goOnly := &models.Article{Title: "Go", Content: "Go", Tags: []string{"go"}, Status: models.StatusPublished, CreationTimestamp: time.Date(2024, 12, 11, 9, 0, 1, 0, time.UTC)}
+	both := &models.Article{Title: "Both", Content: "Both", Tags: []string{"go", "postgres"}, Status: models.StatusPublished, CreationTimestamp: time.Date(2024, 12, 11, 9, 0, 2, 0, time.UTC)}
+	postgresOnly := &models.Article{Title: "Postgres", Content: "Postgres", Tags: []string{"postgres"}, Status: models.StatusPublished, CreationTimestamp: time.Date(2024, 12, 11, 9, 0, 3, 0, time.UTC)}
+	for _, article := range []*models.Article{goOnly, both, postgresOnly} {
+		require.NoError(t, repo.CreateArticle(ctx, article))
+	}
+	createArticle(t, repo, "Untagged", 4)
+
+	for _, test := range []struct {
+		name     string
+		filter   models.ArticleFilter
+		expected []int
+	}{
+		{"Any tag", models.ArticleFilter{Tags: []string{"go", "postgres"}}, []int{goOnly.Id, both.Id, postgresOnly.Id}},
+		{"All tags", models.ArticleFilter{Tags: []string{"go", "postgres"}, MatchAllTags: true}, []int{both.Id}},
+		{"Single tag", models.ArticleFilter{Tags: []string{"postgres"}}, []int{both.Id, postgresOnly.Id}},
+		{"Unknown tag", models.ArticleFilter{Tags: []string{"rust"}}, []int{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			articles, err := repo.GetArticles(ctx, 10, nil, test.filter)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, articleIds(articles))
+		})
+	}
+	t.Run("Paginated", func(t *testing.T) {
+		filter := models.ArticleFilter{Tags: []string{"go"}}
+		firstPage, err := repo.GetArticles(ctx, 1, nil, filter)
+		require.NoError(t, err)
+		secondPage, err := repo.GetArticles(ctx, 1, &pagination.Cursor{Timestamp: firstPage[0].CreationTimestamp, Id: firstPage[0].Id}, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []int{goOnly.Id, both.Id}, append(articleIds(firstPage), articleIds(secondPage)...))
+	})
+}
+
+func testArticleStatus(t *testing.T, repo Storage) {
+	// Given
+	article := createArticle(t, repo, "Awesome Go", 0)
+
+	// The status changes only from the expected status
+	require.NoError(t, repo.SetArticleStatus(ctx, article.Id, models.StatusPublished, models.StatusArchived, nil))
+	assert.ErrorIs(t, repo.SetArticleStatus(ctx, article.Id, models.StatusPublished, models.StatusDraft, nil), ErrRecordNotFound,
+		"The status must not change if the article isn't in the expected status anymore")
+	assert.ErrorIs(t, repo.SetArticleStatus(ctx, 404, models.StatusDraft, models.StatusPublished, nil), ErrRecordNotFound)
+	stored, err := repo.GetArticleById(ctx, article.Id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusArchived, stored.Status)
+
+	// Updating keeps the status
+	update := &models.Article{Id: article.Id, Title: "Awesome Go!", Content: "Updated", Status: models.StatusPublished}
+	require.NoError(t, repo.UpdateArticle(ctx, update))
+	assert.Equal(t, models.StatusArchived, update.Status, "Updating must return the stored status")
+	stored, err = repo.GetArticleById(ctx, article.Id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusArchived, stored.Status)
+}
+
+func testArticlePublishAtOnCreation(t *testing.T, repo Storage) {
+	// Given
+	publishAt := time.Date(2030, 1, 2, 15, 4, 5, 123456789, time.FixedZone("UTC+2", 2*60*60))
+	draft := &models.Article{Title: "Draft", Content: "Draft", Status: models.StatusDraft, PublishAt: &publishAt}
+	scheduled := &models.Article{Title: "Scheduled", Content: "Scheduled", Status: models.StatusScheduled, PublishAt: &publishAt}
+
+	// When
+	require.NoError(t, repo.CreateArticle(ctx, draft))
+	require.NoError(t, repo.CreateArticle(ctx, scheduled))
+
+	// Then
+	stored, err := repo.GetArticleById(ctx, draft.Id)
+	require.NoError(t, err)
+	assert.Nil(t, draft.PublishAt, "Only scheduled articles may have a publish_at")
+	assert.Nil(t, stored.PublishAt, "Only scheduled articles may have a publish_at")
+	stored, err = repo.GetArticleById(ctx, scheduled.Id)
+	require.NoError(t, err)
+	expected := time.Date(2030, 1, 2, 13, 4, 5, 123456000, time.UTC)
+	require.NotNil(t, stored.PublishAt)
+	assert.Equal(t, expected, *scheduled.PublishAt)
+	assert.True(t, expected.Equal(*stored.PublishAt))
+}
+
+func testArticlesStatusFilter(t *testing.T, repo Storage) {
+	// Given
+	published := createArticle(t, repo, "Published gophers", 1)
+	draft := &models.Article{Title: "Draft gophers", Content: "Draft", Tags: []string{"go"}, Status: models.StatusDraft,
+		CreationTimestamp: time.Date(2024, 12, 11, 9, 0, 2, 0, time.UTC)}
+	require.NoError(t, repo.CreateArticle(ctx, draft))
+	published.Tags = []string{"go", "lists"}
+	require.NoError(t, repo.UpdateArticle(ctx, published))
+
+	t.Run("Listing", func(t *testing.T) {
+		articles, err := repo.GetArticles(ctx, 10, nil, models.ArticleFilter{Status: models.StatusDraft})
+		require.NoError(t, err)
+		assert.Equal(t, []int{draft.Id}, articleIds(articles))
+		articles, err = repo.GetArticles(ctx, 10, nil, models.ArticleFilter{Tags: []string{"go"}, Status: models.StatusPublished})
+		require.NoError(t, err)
+		assert.Equal(t, []int{published.Id}, articleIds(articles))
+		articles, err = repo.GetArticles(ctx, 10, nil, models.ArticleFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, []int{published.Id, draft.Id}, articleIds(articles), "An empty status must select all the articles")
+	})
+	t.Run("Search", func(t *testing.T) {
+		results, err := repo.SearchArticles(ctx, "gophers", models.StatusPublished, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, published.Id, results[0].Id)
+		assert.Equal(t, models.StatusPublished, results[0].Status)
+		results, err = repo.SearchArticles(ctx, "gophers", "", 10, 0)
+		require.NoError(t, err)
+		assert.Len(t, results, 2)
+	})
+	t.Run("Tags", func(t *testing.T) {
+		tags, err := repo.GetTags(ctx, models.StatusPublished)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "go", Count: 1}, {Name: "lists", Count: 1}}, tags)
+		tags, err = repo.GetTags(ctx, models.StatusDraft)
+		require.NoError(t, err)
+		assert.Equal(t, []models.TagCount{{Name: "go", Count: 1}}, tags)
+	})
+}
+
+func testPublishDueArticles(t *testing.T, repo Storage) {
+	// Given
+	now := time.Date(2024, 12, 11, 12, 0, 0, 0, time.UTC)
+	schedule := func(title string, publishAt time.Time) *models.Article {
+		article := createArticle(t, repo, title, 0)
+		require.NoError(t, repo.SetArticleStatus(ctx, article.Id, models.StatusPublished, models.StatusDraft, nil))
+		require.NoError(t, repo.SetArticleStatus(ctx, article.Id, models.StatusDraft, models.StatusScheduled, &publishAt))
+		return article
+	}
+	dueLast := schedule("Due last", now.Add(-time.Minute))
+	dueFirst := schedule("Due first", now.Add(-time.Hour))
+	dueNow := schedule("Due now", now)
+	notDue := schedule("Not due", now.Add(time.Second))
+	draft := createArticle(t, repo, "Draft", 0)
+	require.NoError(t, repo.SetArticleStatus(ctx, draft.Id, models.StatusPublished, models.StatusDraft, nil))
+
+	// The publish_at is stored
+	stored, err := repo.GetArticleById(ctx, notDue.Id)
+	require.NoError(t, err)
+	require.NotNil(t, stored.PublishAt)
+	assert.True(t, now.Add(time.Second).Equal(*stored.PublishAt))
+
+	// When
+	firstBatch, err := repo.PublishDueArticles(ctx, now, 2)
+	require.NoError(t, err)
+	secondBatch, err := repo.PublishDueArticles(ctx, now, 2)
+	require.NoError(t, err)
+	thirdBatch, err := repo.PublishDueArticles(ctx, now, 2)
+	require.NoError(t, err)
+
+	// Then
+	assert.ElementsMatch(t, []int{dueFirst.Id, dueLast.Id}, firstBatch, "The earliest due articles must be published first")
+	assert.Equal(t, []int{dueNow.Id}, secondBatch)
+	assert.Empty(t, thirdBatch, "Published articles must not be published again")
+	stored, err = repo.GetArticleById(ctx, dueFirst.Id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPublished, stored.Status)
+	assert.Nil(t, stored.PublishAt, "The publish_at must be cleared once the article is published")
+	stored, err = repo.GetArticleById(ctx, notDue.Id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusScheduled, stored.Status)
+	stored, err = repo.GetArticleById(ctx, draft.Id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDraft, stored.Status)
+}
+
+func testArticleRevisions(t *testing.T, repo Storage) {
+	// Given an article that's created, updated, scheduled and published by the scheduler
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Tags: []string{"go"}, Status: models.StatusDraft}
+	require.NoError(t, repo.CreateArticle(ctx, article))
+	require.NoError(t, repo.UpdateArticle(ctx, &models.Article{Id: article.Id, Title: "Awesome Go!", Content: "Updated", Tags: []string{"go", "news"}}))
+	publishAt := time.Now().Add(-time.Second)
+	require.NoError(t, repo.SetArticleStatus(ctx, article.Id, models.StatusDraft, models.StatusScheduled, &publishAt))
+	_, err := repo.PublishDueArticles(ctx, time.Now(), 10)
+	require.NoError(t, err)
+	other := createArticle(t, repo, "Other", 0)
+
+	// When
+	revisions, err := repo.GetArticleRevisions(ctx, article.Id, 10, 0)
+
+	// Then the latest revision comes first
+	require.NoError(t, err)
+	require.Len(t, revisions, 4)
+	assert.Equal(t, []int{4, 3, 2, 1}, []int{revisions[0].Revision, revisions[1].Revision, revisions[2].Revision, revisions[3].Revision})
+	assert.Equal(t, models.StatusPublished, revisions[0].Status)
+	assert.Equal(t, models.StatusScheduled, revisions[1].Status)
+	first := revisions[3]
+	assert.Equal(t, article.Id, first.ArticleId)
+	assert.Equal(t, "Awesome Go", first.Title)
+	assert.Equal(t, "Awesome", first.Content)
+	assert.Equal(t, []string{"go"}, first.Tags)
+	assert.Equal(t, models.StatusDraft, first.Status)
+	assert.False(t, first.CreationTimestamp.IsZero())
+
+	// When paginated
+	page, err := repo.GetArticleRevisions(ctx, article.Id, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, []int{page[0].Revision, page[1].Revision}, "Only the revisions before 3 must be returned")
+
+	// When fetched by its number
+	revision, err := repo.GetArticleRevision(ctx, article.Id, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Awesome Go!", revision.Title)
+	assert.Equal(t, "Updated", revision.Content)
+	assert.Equal(t, []string{"go", "news"}, revision.Tags)
+	_, err = repo.GetArticleRevision(ctx, article.Id, 5)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+	_, err = repo.GetArticleRevision(ctx, other.Id, 2)
+	assert.ErrorIs(t, err, ErrRecordNotFound, "Revisions must be numbered per article")
+
+	// When the article is deleted
+	require.NoError(t, repo.DeleteArticle(ctx, article.Id))
+	revisions, err = repo.GetArticleRevisions(ctx, article.Id, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, revisions, "The revisions must be deleted with the article")
+}
+
+func testArticleSlugs(t *testing.T, repo Storage) {
+	// Given three articles with the same base slug
+	first := createSluggedArticle(t, repo, "awesome-go")
+	second := createSluggedArticle(t, repo, "awesome-go")
+	third := createSluggedArticle(t, repo, "awesome-go")
+
+	// Then the later ones get a suffix
+	assert.Equal(t, []string{"awesome-go", "awesome-go-2", "awesome-go-3"}, []string{first.Slug, second.Slug, third.Slug})
+	stored, err := repo.GetArticleById(ctx, second.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "awesome-go-2", stored.Slug)
+
+	// When the first one is renamed
+	renamed := &models.Article{Id: first.Id, Slug: "awesome-rust", Title: "Awesome Rust", Content: "Awesome"}
+	require.NoError(t, repo.UpdateArticle(ctx, renamed))
+
+	// Then its old slug still leads to it
+	assert.Equal(t, "awesome-rust", renamed.Slug)
+	found, err := repo.GetArticleBySlug(ctx, "awesome-go")
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, found.Id)
+	assert.Equal(t, "awesome-rust", found.Slug, "The current slug must be returned")
+
+	// And no other article can take it
+	fourth := createSluggedArticle(t, repo, "awesome-go")
+	assert.Equal(t, "awesome-go-4", fourth.Slug)
+
+	// When an update keeps the base slug
+	require.NoError(t, repo.UpdateArticle(ctx, &models.Article{Id: second.Id, Slug: "awesome-go", Title: "Awesome Go", Content: "Updated"}))
+	stored, err = repo.GetArticleById(ctx, second.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "awesome-go-2", stored.Slug, "The slug must not change while the title doesn't")
+
+	// When the first one gets its old title back
+	reverted := &models.Article{Id: first.Id, Slug: "awesome-go", Title: "Awesome Go", Content: "Awesome"}
+	require.NoError(t, repo.UpdateArticle(ctx, reverted))
+	assert.Equal(t, "awesome-go", reverted.Slug, "An article must be able to take its old slug back")
+
+	// When an update has no base slug
+	unchanged := &models.Article{Id: third.Id, Title: "Awesome Go", Content: "Updated"}
+	require.NoError(t, repo.UpdateArticle(ctx, unchanged))
+	assert.Equal(t, "awesome-go-3", unchanged.Slug, "An empty base slug must keep the current slug")
+
+	// When the first one is deleted
+	require.NoError(t, repo.DeleteArticle(ctx, first.Id))
+	_, err = repo.GetArticleBySlug(ctx, "awesome-rust")
+	assert.ErrorIs(t, err, ErrRecordNotFound, "The slugs of deleted articles must be freed")
+	assert.Equal(t, "awesome-rust", createSluggedArticle(t, repo, "awesome-rust").Slug)
+	_, err = repo.GetArticleBySlug(ctx, "missing")
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+
+	t.Run("Titles ending with a number", func(t *testing.T) {
+		// Given
+		topTen := createSluggedArticle(t, repo, "top-10")
+		otherTopTen := createSluggedArticle(t, repo, "top-10")
+
+		// When renamed to a free base
+		top := &models.Article{Id: topTen.Id, Slug: "top", Title: "Top", Content: "Awesome"}
+		require.NoError(t, repo.UpdateArticle(ctx, top))
+
+		// Then
+		assert.Equal(t, "top", top.Slug, "A slug ending with a number isn't a collision suffix of the new base")
+
+		// When renamed to a taken base
+		otherTop := &models.Article{Id: otherTopTen.Id, Slug: "top", Title: "Top", Content: "Awesome"}
+		require.NoError(t, repo.UpdateArticle(ctx, otherTop))
+
+		// Then it gets a suffix of the new base which is kept on later updates
+		assert.Equal(t, "top-2", otherTop.Slug)
+		updated := &models.Article{Id: otherTopTen.Id, Slug: "top", Title: "Top", Content: "Updated"}
+		require.NoError(t, repo.UpdateArticle(ctx, updated))
+		assert.Equal(t, "top-2", updated.Slug)
+	})
+}
+
+func testComments(t *testing.T, repo Storage) {
+	// Given
+	article := createArticle(t, repo, "Awesome Go", 0)
+	root := &models.Comment{ArticleId: article.Id, Author: "Ahmed Ehab", Content: "Awesome"}
+	require.NoError(t, repo.CreateComment(ctx, root))
+	reply := &models.Comment{ArticleId: article.Id, ParentId: &root.Id, Author: "John Doe", Content: "Indeed"}
+	require.NoError(t, repo.CreateComment(ctx, reply))
+
+	// When
+	comments, err := repo.GetCommentsByArticleId(ctx, article.Id)
+	require.NoError(t, err)
+
+	// Then
+	require.Len(t, comments, 2)
+	assert.Equal(t, reply.Id, comments[1].Id, "The id must be filled on creation")
+	assert.Equal(t, root.Id, *comments[1].ParentId)
+	assert.False(t, comments[1].CreationTimestamp.IsZero(), "The creation timestamp must default to now")
+	assert.True(t, reply.CreationTimestamp.Equal(comments[1].CreationTimestamp), "The stored creation timestamp must be filled on creation")
+	stored, err := repo.GetCommentById(ctx, reply.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Indeed", stored.Content)
+
+	// Deleting the article deletes its comments
+	require.NoError(t, repo.DeleteArticle(ctx, article.Id))
+	_, err = repo.GetCommentById(ctx, root.Id)
+	assert.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func testCommentsForeignKeys(t *testing.T, repo Storage) {
+	err := repo.CreateComment(ctx, &models.Comment{ArticleId: 404, Author: "Ahmed Ehab", Content: "Awesome"})
+	assert.ErrorIs(t, err, ErrForeignKeyViolation)
+
+	article := createArticle(t, repo, "Awesome Go", 0)
+	missingParent := 404
+	err = repo.CreateComment(ctx, &models.Comment{ArticleId: article.Id, ParentId: &missingParent, Author: "Ahmed Ehab", Content: "Awesome"})
+	assert.ErrorIs(t, err, ErrForeignKeyViolation)
+}
+
+func testCommentsScopedToArticle(t *testing.T, repo Storage) {
+	// Given
+	article := createArticle(t, repo, "Awesome Go", 1)
+	other := createArticle(t, repo, "Other", 2)
+	own := createComment(t, repo, article.Id, 1)
+	createComment(t, repo, other.Id, 2)
+
+	// When
+	comments, err := repo.GetCommentsByArticleId(ctx, article.Id)
+	require.NoError(t, err)
+	page, err := repo.GetCommentsPage(ctx, article.Id, models.CommentSortOldest, 10, nil)
+	require.NoError(t, err)
+	missing, err := repo.GetCommentsByArticleId(ctx, 404)
+	require.NoError(t, err)
+
+	// Then
+	assert.Equal(t, []int{own.Id}, commentIds(comments), "Only the comments of the article must be returned")
+	assert.Equal(t, []int{own.Id}, commentIds(page), "Only the comments of the article must be returned")
+	assert.Empty(t, missing)
+}
+
+func testCommentsPagination(t *testing.T, repo Storage) {
+	// Given comments created in a different order than their timestamps
+	article := createArticle(t, repo, "Awesome Go", 0)
+	third := createComment(t, repo, article.Id, 3)
+	first := createComment(t, repo, article.Id, 1)
+	second := createComment(t, repo, article.Id, 2)
+
+	for _, test := range []struct {
+		sort     models.CommentSort
+		expected [][]int
+	}{
+		{models.CommentSortOldest, [][]int{{first.Id, second.Id}, {third.Id}}},
+		{models.CommentSortNewest, [][]int{{third.Id, second.Id}, {first.Id}}},
+	} {
+		t.Run(string(test.sort), func(t *testing.T) {
+			// When
+			firstPage, err := repo.GetCommentsPage(ctx, article.Id, test.sort, 2, nil)
+			require.NoError(t, err)
+			last := firstPage[len(firstPage)-1]
+			secondPage, err := repo.GetCommentsPage(ctx, article.Id, test.sort, 2, &pagination.Cursor{Timestamp: last.CreationTimestamp, Id: last.Id})
+			require.NoError(t, err)
+
+			// Then
+			assert.Equal(t, test.expected, [][]int{commentIds(firstPage), commentIds(secondPage)})
+		})
+	}
+}
+
+func testCanceledContext(t *testing.T, repo Storage) {
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	_, err := repo.GetArticles(canceled, 10, nil, models.ArticleFilter{})
+	assert.ErrorIs(t, err, context.Canceled)
+	err = repo.CreateArticle(canceled, &models.Article{Title: "Awesome Go", Content: "Awesome"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+// createArticle creates a published article with a timestamp offset by the provided seconds
+func createArticle(t *testing.T, repo Storage, title string, seconds int) *models.Article {
+	article := &models.Article{Title: title, Content: title + " content", Status: models.StatusPublished, CreationTimestamp: time.Date(2024, 12, 11, 9, 0, seconds, 0, time.UTC)}
+	require.NoError(t, repo.CreateArticle(ctx, article))
+	require.NotZero(t, article.Id, "The id must be filled on creation")
+	return article
+}
+
+// createSluggedArticle creates an article with the base slug, see freeSlug
+func createSluggedArticle(t *testing.T, repo Storage, base string) *models.Article {
+	article := &models.Article{Slug: base, Title: base, Content: "Awesome", Status: models.StatusDraft}
+	require.NoError(t, repo.CreateArticle(ctx, article))
+	return article
+}
+
+// createComment creates a top-level comment with a timestamp offset by the provided seconds
+func createComment(t *testing.T, repo Storage, articleId int, seconds int) *models.Comment {
+	comment := &models.Comment{ArticleId: articleId, Author: "Ahmed Ehab", Content: "Awesome", CreationTimestamp: time.Date(2024, 12, 11, 10, 0, seconds, 0, time.UTC)}
+	require.NoError(t, repo.CreateComment(ctx, comment))
+	require.NotZero(t, comment.Id, "The id must be filled on creation")
+	return comment
+}
+
+// roundTrip encodes and decodes the cursor like it goes through the API
+func roundTrip(t *testing.T, cursor *pagination.Cursor) *pagination.Cursor {
+	decoded, err := pagination.Decode(cursor.Encode())
+	require.NoError(t, err)
+	return decoded
+}
+
+func articleIds(articles []models.Article) []int {
+	ids := []int{}
+	for _, article := range articles {
+		ids = append(ids, article.Id)
+	}
+	return ids
+}
+
+func commentIds(comments []models.Comment) []int {
+	ids := []int{}
+	for _, comment := range comments {
+		ids = append(ids, comment.Id)
+	}
+	return ids
+}
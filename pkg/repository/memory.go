@@ -0,0 +1,470 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+)
+
+// MemoryRepository is a thread-safe in-memory implementation of all the repository interfaces
+// meant for tests and local demos, it follows the same semantics as the database repositories
+// including auto incremented ids, default timestamps, foreign key checks and failing on done contexts
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	articles      map[int]models.Article
+	comments      map[int]models.Comment
+	revisions     map[int][]models.ArticleRevision // by article id, in the order they're recorded
+	slugs         map[string]slugOwner             // the current and previous slugs of the articles
+	lastArticleId int
+	lastCommentId int
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{articles: map[int]models.Article{}, comments: map[int]models.Comment{}, revisions: map[int][]models.ArticleRevision{},
+		slugs: map[string]slugOwner{}}
+}
+
+func (repo *MemoryRepository) GetArticleById(ctx context.Context, id int) (*models.Article, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	article, ok := repo.articles[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return &article, nil
+}
+
+func (repo *MemoryRepository) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	owner, ok := repo.slugs[slug]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	article := repo.articles[owner.articleId]
+	return &article, nil
+}
+
+func (repo *MemoryRepository) GetArticles(ctx context.Context, limit int, after *pagination.Cursor, filter models.ArticleFilter) ([]models.Article, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := []models.Article{}
+	for _, article := range repo.sortedArticles() {
+		if after != nil && compareToCursor(article.CreationTimestamp, article.Id, after) <= 0 {
+			continue
+		}
+		if !matchesFilter(article, filter) {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, article)
+	}
+	return result, nil
+}
+
+// SearchArticles matches articles containing all the words of the query in their title or content ignoring case,
+// it's a simplified version of the database full-text search without stemming
+func (repo *MemoryRepository) SearchArticles(ctx context.Context, query string, status models.ArticleStatus, limit int, offset int) ([]models.ArticleSearchResult, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	terms := strings.Fields(strings.ToLower(query))
+	matches := []models.ArticleSearchResult{}
+	for _, article := range repo.sortedArticles() {
+		if status != "" && article.Status != status {
+			continue
+		}
+		rank, ok := rankArticle(article, terms)
+		if ok {
+			matches = append(matches, models.ArticleSearchResult{Article: article, Rank: rank, Snippet: highlight(article.Content, terms)})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b models.ArticleSearchResult) int {
+		return cmp.Or(cmp.Compare(b.Rank, a.Rank), cmp.Compare(a.Id, b.Id))
+	})
+	if offset >= len(matches) {
+		return []models.ArticleSearchResult{}, nil
+	}
+	return matches[offset:min(offset+limit, len(matches))], nil
+}
+
+func (repo *MemoryRepository) CreateArticle(ctx context.Context, article *models.Article) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if article.CreationTimestamp.IsZero() {
+		article.CreationTimestamp = time.Now()
+	}
+	article.CreationTimestamp = storedTime(article.CreationTimestamp)
+	article.PublishAt = createdPublishAt(article)
+	repo.lastArticleId++
+	article.Id = repo.lastArticleId
+	article.Slug = repo.setSlug(article.Id, article.Slug, "")
+	article.Tags = sortedTags(article.Tags)
+	repo.articles[article.Id] = *article
+	repo.recordRevision(*article)
+	return nil
+}
+
+func (repo *MemoryRepository) UpdateArticle(ctx context.Context, article *models.Article) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored, ok := repo.articles[article.Id]
+	if !ok {
+		return ErrRecordNotFound
+	}
+	article.Slug = repo.setSlug(article.Id, article.Slug, stored.Slug)
+	article.Tags = sortedTags(article.Tags)
+	stored.Slug, stored.Title, stored.Content, stored.Tags = article.Slug, article.Title, article.Content, article.Tags
+	repo.articles[article.Id] = stored
+	repo.recordRevision(stored)
+	article.Status, article.PublishAt, article.CreationTimestamp = stored.Status, stored.PublishAt, stored.CreationTimestamp
+	return nil
+}
+
+func (repo *MemoryRepository) SetArticleStatus(ctx context.Context, id int, from models.ArticleStatus, to models.ArticleStatus, publishAt *time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stored, ok := repo.articles[id]
+	if !ok || stored.Status != from {
+		return ErrRecordNotFound
+	}
+	stored.Status, stored.PublishAt = to, publishAt
+	repo.articles[id] = stored
+	repo.recordRevision(stored)
+	return nil
+}
+
+// PublishDueArticles publishes up to limit scheduled articles whose publish_at is before now, the earliest first,
+// and returns their ids, the lock makes it safe for concurrent runs
+func (repo *MemoryRepository) PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	due := []models.Article{}
+	for _, article := range repo.articles {
+		if article.Status == models.StatusScheduled && article.PublishAt != nil && !article.PublishAt.After(now) {
+			due = append(due, article)
+		}
+	}
+	slices.SortFunc(due, func(a, b models.Article) int {
+		return cmp.Or(a.PublishAt.Compare(*b.PublishAt), cmp.Compare(a.Id, b.Id))
+	})
+	ids := []int{}
+	for _, article := range due[:min(limit, len(due))] {
+		article.Status, article.PublishAt = models.StatusPublished, nil
+		repo.articles[article.Id] = article
+		repo.recordRevision(article)
+		ids = append(ids, article.Id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (repo *MemoryRepository) DeleteArticle(ctx context.Context, id int) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := repo.articles[id]; !ok {
+		return ErrRecordNotFound
+	}
+	for commentId, comment := range repo.comments {
+		if comment.ArticleId == id {
+			delete(repo.comments, commentId)
+		}
+	}
+	delete(repo.articles, id)
+	delete(repo.revisions, id)
+	for slug, owner := range repo.slugs {
+		if owner.articleId == id {
+			delete(repo.slugs, slug)
+		}
+	}
+	return nil
+}
+
+// GetArticleRevisions returns up to limit revisions of the article, the latest first, starting right before the revision
+// before if it's positive
+func (repo *MemoryRepository) GetArticleRevisions(ctx context.Context, articleId int, limit int, before int) ([]models.ArticleRevision, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := []models.ArticleRevision{}
+	revisions := repo.revisions[articleId]
+	for i := len(revisions) - 1; i >= 0 && len(result) < limit; i-- {
+		if before <= 0 || revisions[i].Revision < before {
+			result = append(result, revisions[i])
+		}
+	}
+	return result, nil
+}
+
+func (repo *MemoryRepository) GetArticleRevision(ctx context.Context, articleId int, revision int) (*models.ArticleRevision, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	revisions := repo.revisions[articleId]
+	if revision < 1 || revision > len(revisions) {
+		return nil, ErrRecordNotFound
+	}
+	result := revisions[revision-1]
+	return &result, nil
+}
+
+// GetTags returns the tags used by at least one article having the status (any status if it's empty)
+// with their number of articles, the most used first
+func (repo *MemoryRepository) GetTags(ctx context.Context, status models.ArticleStatus) ([]models.TagCount, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	for _, article := range repo.articles {
+		if status != "" && article.Status != status {
+			continue
+		}
+		for _, tag := range article.Tags {
+			counts[tag]++
+		}
+	}
+	result := []models.TagCount{}
+	for name, count := range counts {
+		result = append(result, models.TagCount{Name: name, Count: count})
+	}
+	slices.SortFunc(result, func(a, b models.TagCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	return result, nil
+}
+
+func (repo *MemoryRepository) GetCommentById(ctx context.Context, id int) (*models.Comment, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	comment, ok := repo.comments[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	return &comment, nil
+}
+
+func (repo *MemoryRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := repo.articles[comment.ArticleId]; !ok {
+		return ErrForeignKeyViolation
+	}
+	if comment.ParentId != nil {
+		if _, ok := repo.comments[*comment.ParentId]; !ok {
+			return ErrForeignKeyViolation
+		}
+	}
+	if comment.CreationTimestamp.IsZero() {
+		comment.CreationTimestamp = time.Now()
+	}
+	comment.CreationTimestamp = storedTime(comment.CreationTimestamp)
+	repo.lastCommentId++
+	comment.Id = repo.lastCommentId
+	stored := *comment
+	stored.Replies = nil
+	repo.comments[comment.Id] = stored
+	return nil
+}
+
+func (repo *MemoryRepository) GetCommentsByArticleId(ctx context.Context, articleId int) ([]models.Comment, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result := []models.Comment{}
+	for _, comment := range repo.comments {
+		if comment.ArticleId == articleId {
+			result = append(result, comment)
+		}
+	}
+	slices.SortFunc(result, func(a, b models.Comment) int {
+		return cmp.Or(a.CreationTimestamp.Compare(b.CreationTimestamp), cmp.Compare(a.Id, b.Id))
+	})
+	return result, nil
+}
+
+// GetCommentsPage returns up to limit comments of the article in the requested order,
+// starting right after the cursor if provided
+func (repo *MemoryRepository) GetCommentsPage(ctx context.Context, articleId int, sort models.CommentSort, limit int, after *pagination.Cursor) ([]models.Comment, error) {
+	comments, err := repo.GetCommentsByArticleId(ctx, articleId)
+	if err != nil {
+		return nil, err
+	}
+	direction := 1
+	if sort == models.CommentSortNewest {
+		slices.Reverse(comments)
+		direction = -1
+	}
+	result := []models.Comment{}
+	for _, comment := range comments {
+		if after != nil && direction*compareToCursor(comment.CreationTimestamp, comment.Id, after) <= 0 {
+			continue
+		}
+		if len(result) == limit {
+			break
+		}
+		result = append(result, comment)
+	}
+	return result, nil
+}
+
+// recordRevision snapshots the stored article as its next revision, the caller must hold the write lock
+func (repo *MemoryRepository) recordRevision(article models.Article) {
+	revisions := repo.revisions[article.Id]
+	repo.revisions[article.Id] = append(revisions, models.ArticleRevision{
+		ArticleId:         article.Id,
+		Revision:          len(revisions) + 1,
+		Title:             article.Title,
+		Content:           article.Content,
+		Tags:              slices.Clone(article.Tags),
+		Status:            article.Status,
+		CreationTimestamp: storedTime(time.Now()),
+	})
+}
+
+// setSlug returns the new slug of the article made out of the base slug, see freeSlug, and keeps the current one
+// leading to it, an empty base keeps the current slug. The caller must hold the write lock
+func (repo *MemoryRepository) setSlug(articleId int, base string, current string) string {
+	if base == "" {
+		return current
+	}
+	slug := freeSlug(base, current, articleId, repo.slugs)
+	repo.slugs[slug] = slugOwner{articleId, base}
+	return slug
+}
+
+// storedTime normalizes timestamps like the databases store them, see sqliteTime, so the timestamps
+// of the stored rows match the microsecond precision of the pagination cursors
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// sortedArticles returns the articles ordered by creation_timestamp then id, the caller must hold the lock
+func (repo *MemoryRepository) sortedArticles() []models.Article {
+	result := make([]models.Article, 0, len(repo.articles))
+	for _, article := range repo.articles {
+		result = append(result, article)
+	}
+	slices.SortFunc(result, func(a, b models.Article) int {
+		return cmp.Or(a.CreationTimestamp.Compare(b.CreationTimestamp), cmp.Compare(a.Id, b.Id))
+	})
+	return result
+}
+
+func compareToCursor(timestamp time.Time, id int, cursor *pagination.Cursor) int {
+	return cmp.Or(timestamp.Compare(cursor.Timestamp), cmp.Compare(id, cursor.Id))
+}
+
+// matchesFilter returns whether the article has the filter's status if it's set, and all the tags of the filter
+// when MatchAllTags is set or any of them otherwise
+func matchesFilter(article models.Article, filter models.ArticleFilter) bool {
+	if filter.Status != "" && article.Status != filter.Status {
+		return false
+	}
+	if len(filter.Tags) == 0 {
+		return true
+	}
+	for _, tag := range filter.Tags {
+		found := slices.Contains(article.Tags, tag)
+		if found && !filter.MatchAllTags {
+			return true
+		}
+		if !found && filter.MatchAllTags {
+			return false
+		}
+	}
+	return filter.MatchAllTags
+}
+
+// sortedTags returns a sorted copy of the tags like the database repositories return them
+func sortedTags(tags []string) []string {
+	result := append([]string{}, tags...)
+	slices.Sort(result)
+	return result
+}
+
+// rankArticle returns whether all the terms are in the article, the rank favors title matches like the database search
+func rankArticle(article models.Article, terms []string) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+	title, content := strings.ToLower(article.Title), strings.ToLower(article.Content)
+	rank := 0.0
+	for _, term := range terms {
+		titleHits, contentHits := strings.Count(title, term), strings.Count(content, term)
+		if titleHits+contentHits == 0 {
+			return 0, false
+		}
+		rank += float64(titleHits) + 0.4*float64(contentHits)
+	}
+	return rank / float64(len(terms)), true
+}
+
+// highlight wraps the occurrences of the terms in the text in <mark> tags ignoring case
+func highlight(text string, terms []string) string {
+	var builder strings.Builder
+	for i := 0; i < len(text); {
+		matched := 0
+		for _, term := range terms {
+			end := i + len(term)
+			if end <= len(text) && len(term) > matched && strings.EqualFold(text[i:end], term) {
+				matched = len(term)
+			}
+		}
+		if matched == 0 {
+			builder.WriteByte(text[i])
+			i++
+			continue
+		}
+		builder.WriteString("<mark>" + text[i:i+matched] + "</mark>")
+		i += matched
+	}
+	return builder.String()
+}
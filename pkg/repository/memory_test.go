@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Storage { return NewMemoryRepository() })
+}
+
+func TestMemoryRepositoryConcurrentCreation(t *testing.T) {
+	// Given
+	repo := NewMemoryRepository()
+	var wg sync.WaitGroup
+
+	// When
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.CreateArticle(ctx, &models.Article{Title: "Awesome", Content: "Awesome"})
+		}()
+	}
+	wg.Wait()
+
+	// Then
+	articles, _ := repo.GetArticles(ctx, 100, nil, models.ArticleFilter{})
+	ids := map[int]bool{}
+	for _, article := range articles {
+		ids[article.Id] = true
+	}
+	assert.Len(t, ids, 50, "Every article must get a unique id")
+}
@@ -1,12 +1,22 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
 	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
 	"github.com/jackc/pgx/v5/pgconn"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
 /*
@@ -16,76 +26,626 @@ import (
  */
 
 type Repository struct {
-	db *sql.DB
+	db *tracing.DB
 }
 
 type ArticleRepository interface {
-	GetArticleById(id int) (*models.Article, error)
-	GetArticles() ([]models.Article, error)
-	CreateArticle(article *models.Article) error
+	GetArticleById(ctx context.Context, id int) (*models.Article, error)
+	GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error)
+	GetArticles(ctx context.Context, limit int, after *pagination.Cursor, filter models.ArticleFilter) ([]models.Article, error)
+	SearchArticles(ctx context.Context, query string, status models.ArticleStatus, limit int, offset int) ([]models.ArticleSearchResult, error)
+	CreateArticle(ctx context.Context, article *models.Article) error
+	UpdateArticle(ctx context.Context, article *models.Article) error
+	SetArticleStatus(ctx context.Context, id int, from models.ArticleStatus, to models.ArticleStatus, publishAt *time.Time) error
+	PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error)
+	DeleteArticle(ctx context.Context, id int) error
+	GetTags(ctx context.Context, status models.ArticleStatus) ([]models.TagCount, error)
+	GetArticleRevisions(ctx context.Context, articleId int, limit int, before int) ([]models.ArticleRevision, error)
+	GetArticleRevision(ctx context.Context, articleId int, revision int) (*models.ArticleRevision, error)
 }
 
 type CommentRepository interface {
-	CreateComment(comment *models.Comment) error
-	GetCommentsByArticleId(articleId int) ([]models.Comment, error)
+	GetCommentById(ctx context.Context, id int) (*models.Comment, error)
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	GetCommentsByArticleId(ctx context.Context, articleId int) ([]models.Comment, error)
+	GetCommentsPage(ctx context.Context, articleId int, sort models.CommentSort, limit int, after *pagination.Cursor) ([]models.Comment, error)
+}
+
+// Storage is implemented by all the repositories as each of them serves both articles and comments
+type Storage interface {
+	ArticleRepository
+	CommentRepository
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	repo := new(Repository)
-	repo.db = db
+	repo.db = tracing.WrapDB(db, semconv.DBSystemPostgreSQL)
 	return repo
 }
 
-const ArticleIdFKErrorContent = "foreign key constraint error occured for article id in comment creation"
+// Repository errors, all the errors returned by the repository are mapped to these when possible
+var (
+	ErrRecordNotFound      = domainerr.NotFound("record_not_found", "no record was found")
+	ErrForeignKeyViolation = domainerr.ForeignKey("foreign_key_violation", "a referenced record doesn't exist")
+	ErrUniqueViolation     = domainerr.Conflict("unique_violation", "a record with the same unique values already exists")
+	ErrConstraintViolation = domainerr.Validation("constraint_violation", "a value doesn't satisfy the database constraints")
+	ErrDatabaseUnavailable = domainerr.Unavailable("database_unavailable", "the database is unavailable")
+)
 
-func (repo *Repository) GetArticleById(id int) (*models.Article, error) {
+// articleTagsColumn selects the tags of the article as a comma separated list, see splitTags
+const articleTagsColumn = "(SELECT string_agg(tag.name, ',') FROM article_tag JOIN tag ON tag.id = article_tag.tag_id " +
+	"WHERE article_tag.article_id = article.id) AS tags"
+
+func (repo *Repository) GetArticleById(ctx context.Context, id int) (*models.Article, error) {
 	article := new(models.Article)
-	result := repo.db.QueryRow("SELECT id, title, content, creation_timestamp FROM article WHERE ID = $1", id)
-	err := result.Scan(&article.Id, &article.Title, &article.Content, &article.CreationTimestamp)
-	return article, err
+	var tags sql.NullString
+	result := repo.db.QueryRowContext(ctx, "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, "+articleTagsColumn+" FROM article WHERE ID = $1", id)
+	err := result.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags)
+	article.Tags = splitTags(tags)
+	return article, mapError(err)
 }
 
-func (repo *Repository) GetArticles() ([]models.Article, error) {
+// GetArticleBySlug returns the article having the slug either as its current slug or as one of its previous ones
+func (repo *Repository) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	article := new(models.Article)
+	var tags sql.NullString
+	result := repo.db.QueryRowContext(ctx, "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, "+articleTagsColumn+
+		" FROM article WHERE id = (SELECT article_id FROM article_slug WHERE slug = $1)", slug)
+	err := result.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags)
+	article.Tags = splitTags(tags)
+	return article, mapError(err)
+}
+
+// GetArticles returns up to limit articles matching the filter ordered by creation_timestamp then id,
+// starting right after the cursor if provided
+func (repo *Repository) GetArticles(ctx context.Context, limit int, after *pagination.Cursor, filter models.ArticleFilter) ([]models.Article, error) {
+	query := "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, " + articleTagsColumn + " FROM article WHERE TRUE"
+	args := []any{}
+	if after != nil {
+		query += " AND (creation_timestamp, id) > ($1, $2)"
+		args = append(args, after.Timestamp, after.Id)
+	}
+	conditions, args := articleFilterConditions(filter, postgresPlaceholder, args)
+	query += conditions + " ORDER BY creation_timestamp, id LIMIT " + postgresPlaceholder(len(args)+1)
+	rows, err := repo.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
 	result := []models.Article{}
-	rows, err := repo.db.Query("SELECT id, title, content, creation_timestamp FROM article")
 	for rows.Next() {
 		article := new(models.Article)
-		rows.Scan(&article.Id, &article.Title, &article.Content, &article.CreationTimestamp)
+		var tags sql.NullString
+		if err = rows.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags); err != nil {
+			return nil, mapError(err)
+		}
+		article.Tags = splitTags(tags)
 		result = append(result, *article)
 	}
-	return result, err
+	return result, mapError(rows.Err())
 }
 
-func (repo *Repository) CreateArticle(article *models.Article) error {
+// SearchArticles runs a full-text search over titles and contents of the articles having the status (any status if it's empty),
+// results are ordered by relevance with title matches weighing more than content matches
+func (repo *Repository) SearchArticles(ctx context.Context, query string, status models.ArticleStatus, limit int, offset int) ([]models.ArticleSearchResult, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, `+articleTagsColumn+`,
+			ts_rank(search_vector, query) AS rank,
+			ts_headline('english', content, query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10')
+		FROM article, websearch_to_tsquery('english', $1) query
+		WHERE search_vector @@ query AND (CAST($2 AS TEXT) = '' OR status = $2)
+		ORDER BY rank DESC, id
+		LIMIT $3 OFFSET $4`, query, string(status), limit, offset)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+	result := []models.ArticleSearchResult{}
+	for rows.Next() {
+		r := new(models.ArticleSearchResult)
+		var tags sql.NullString
+		if err = rows.Scan(&r.Id, &r.Slug, &r.Title, &r.Content, &r.Status, &r.PublishAt, &r.CreationTimestamp, &tags, &r.Rank, &r.Snippet); err != nil {
+			return nil, mapError(err)
+		}
+		r.Tags = splitTags(tags)
+		result = append(result, *r)
+	}
+	return result, mapError(rows.Err())
+}
+
+// CreateArticle inserts the article with its tags as its first revision and fills its generated id, slug and stored creation_timestamp
+// its publish_at is only kept if it's scheduled, see createdPublishAt
+// article.Slug is the base of its slug, see setArticleSlug
+func (repo *Repository) CreateArticle(ctx context.Context, article *models.Article) error {
 	if article.CreationTimestamp.IsZero() {
 		article.CreationTimestamp = time.Now()
 	}
-	_, err := repo.db.Exec("INSERT INTO article(title, content, creation_timestamp) VALUES ($1, $2, $3)",
-		article.Title, article.Content, article.CreationTimestamp)
+	article.PublishAt = createdPublishAt(article)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+	result := tx.QueryRowContext(ctx, "INSERT INTO article(title, content, status, publish_at, creation_timestamp) VALUES ($1, $2, $3, $4, $5) "+
+		"RETURNING id, creation_timestamp", article.Title, article.Content, string(article.Status), article.PublishAt, article.CreationTimestamp)
+	if err = result.Scan(&article.Id, &article.CreationTimestamp); err != nil {
+		return mapError(err)
+	}
+	if err = setArticleSlug(ctx, tx, article); err != nil {
+		return mapError(err)
+	}
+	if err = setArticleTags(ctx, tx, article.Id, article.Tags); err != nil {
+		return mapError(err)
+	}
+	if err = recordRevision(ctx, tx, article.Id); err != nil {
+		return mapError(err)
+	}
+	return mapError(tx.Commit())
+}
+
+// UpdateArticle overwrites the title, content and tags of an existing article and records it as a new revision,
+// status, publish_at and creation_timestamp are kept as is, article.Slug is the base of its new slug, see setArticleSlug
+// returns ErrRecordNotFound if there's no article with the provided id
+func (repo *Repository) UpdateArticle(ctx context.Context, article *models.Article) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+	result := tx.QueryRowContext(ctx, "UPDATE article SET title = $1, content = $2 WHERE id = $3 RETURNING status, publish_at, creation_timestamp",
+		article.Title, article.Content, article.Id)
+	if err = result.Scan(&article.Status, &article.PublishAt, &article.CreationTimestamp); err != nil {
+		return mapError(err)
+	}
+	if err = setArticleSlug(ctx, tx, article); err != nil {
+		return mapError(err)
+	}
+	if err = setArticleTags(ctx, tx, article.Id, article.Tags); err != nil {
+		return mapError(err)
+	}
+	if err = recordRevision(ctx, tx, article.Id); err != nil {
+		return mapError(err)
+	}
+	return mapError(tx.Commit())
+}
+
+// SetArticleStatus changes the status and publish_at of the article only if it's still in the from status and records it
+// as a new revision, returns ErrRecordNotFound if there's no article with the provided id in that status
+func (repo *Repository) SetArticleStatus(ctx context.Context, id int, from models.ArticleStatus, to models.ArticleStatus, publishAt *time.Time) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, "UPDATE article SET status = $1, publish_at = $2 WHERE id = $3 AND status = $4",
+		string(to), publishAt, id, string(from))
+	if err != nil {
+		return mapError(err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if affected == 0 {
+		return ErrRecordNotFound
+	}
+	if err = recordRevision(ctx, tx, id); err != nil {
+		return mapError(err)
+	}
+	return mapError(tx.Commit())
+}
+
+// PublishDueArticles publishes up to limit scheduled articles whose publish_at is before now, the earliest first,
+// and returns their ids, each of them gets a new revision. The rows are locked and the ones locked by another replica
+// are skipped so every article is published by a single replica
+func (repo *Repository) PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, `UPDATE article SET status = 'published', publish_at = NULL
+		WHERE id IN (
+			SELECT id FROM article WHERE status = 'scheduled' AND publish_at <= $1
+			ORDER BY publish_at, id LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`, now.UTC(), limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	ids, err := scanIds(rows)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	for _, id := range ids {
+		if err = recordRevision(ctx, tx, id); err != nil {
+			return nil, mapError(err)
+		}
+	}
+	return ids, mapError(tx.Commit())
+}
+
+// recordRevision snapshots the article as its next revision, it must run in the transaction that changed the article
+// after changing it so the article's row lock serializes the revisions of concurrent changes
+func recordRevision(ctx context.Context, tx *tracing.Tx, articleId int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO article_revision(article_id, revision, title, content, tags, status, creation_timestamp)
+		SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revision WHERE article_id = article.id),
+			title, content, `+articleTagsColumn+`, status, CAST($2 AS TIMESTAMP)
+		FROM article WHERE id = $1`, articleId, time.Now())
+	return err
+}
+
+// createdPublishAt returns the publish_at of an article being created, it's only kept if the article is scheduled
+// and it's normalized to UTC with microsecond precision like the scheduled ones
+func createdPublishAt(article *models.Article) *time.Time {
+	if article.Status != models.StatusScheduled || article.PublishAt == nil {
+		return nil
+	}
+	publishAt := article.PublishAt.UTC().Truncate(time.Microsecond)
+	return &publishAt
+}
+
+// setArticleSlug replaces the slug of the article with a free slug made out of the base slug in article.Slug, see freeSlug,
+// its previous slugs are kept so they keep leading to it. An empty base keeps the current slug
+// The transaction scoped lock on the base slug makes concurrent articles with the same title wait for each other,
+// a suffixed slug is locked too before claiming it since it can be the base of another article, see lockSlug
+func setArticleSlug(ctx context.Context, tx *tracing.Tx, article *models.Article) error {
+	base := article.Slug
+	var current string
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(slug, '') FROM article WHERE id = $1", article.Id).Scan(&current); err != nil {
+		return err
+	}
+	article.Slug = current
+	if base == "" {
+		return nil
+	}
+	if _, err := lockSlug(ctx, tx, base); err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT slug, article_id, base FROM article_slug WHERE slug = $1 OR slug LIKE $2", base, base+"-%")
+	if err != nil {
+		return err
+	}
+	owners, err := scanSlugOwners(rows)
+	if err != nil {
+		return err
+	}
+	article.Slug = freeSlug(base, current, article.Id, owners)
+	for article.Slug != base && owners[article.Slug].articleId != article.Id {
+		owner, err := lockSlug(ctx, tx, article.Slug)
+		if err != nil {
+			return err
+		}
+		if owner == nil {
+			break
+		}
+		owners[article.Slug] = *owner
+		article.Slug = freeSlug(base, current, article.Id, owners)
+	}
+	if owners[article.Slug].articleId == article.Id {
+		_, err = tx.ExecContext(ctx, "UPDATE article_slug SET base = $1 WHERE slug = $2", base, article.Slug)
+	} else {
+		_, err = tx.ExecContext(ctx, "INSERT INTO article_slug(slug, article_id, base, creation_timestamp) VALUES ($1, $2, $3, $4)",
+			article.Slug, article.Id, base, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE article SET slug = $1 WHERE id = $2", article.Slug, article.Id)
 	return err
 }
 
-func (repo *Repository) CreateComment(comment *models.Comment) error {
+// lockSlug takes the transaction scoped lock on the slug and returns its owner, nil if it's free
+// the owner is read after the lock so it includes the slug claimed by a concurrent transaction that held it
+func lockSlug(ctx context.Context, tx *tracing.Tx, slug string) (*slugOwner, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", slug); err != nil {
+		return nil, err
+	}
+	var owner slugOwner
+	err := tx.QueryRowContext(ctx, "SELECT article_id, base FROM article_slug WHERE slug = $1", slug).Scan(&owner.articleId, &owner.base)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &owner, nil
+}
+
+// setArticleTags replaces the tags of the article, the tags that don't exist yet are created
+func setArticleTags(ctx context.Context, tx *tracing.Tx, articleId int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_tag WHERE article_id = $1", articleId); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO tag(name) VALUES ($1) ON CONFLICT (name) DO NOTHING", tag); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO article_tag(article_id, tag_id) SELECT CAST($1 AS INTEGER), id FROM tag WHERE name = $2 "+
+			"ON CONFLICT DO NOTHING", articleId, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTags returns the tags used by at least one article having the status (any status if it's empty)
+// with their number of articles, the most used first
+func (repo *Repository) GetTags(ctx context.Context, status models.ArticleStatus) ([]models.TagCount, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT tag.name, COUNT(*) FROM tag JOIN article_tag ON article_tag.tag_id = tag.id "+
+		"JOIN article ON article.id = article_tag.article_id WHERE CAST($1 AS TEXT) = '' OR article.status = $1 "+
+		"GROUP BY tag.name ORDER BY COUNT(*) DESC, tag.name", string(status))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	tags, err := scanTagCounts(rows)
+	return tags, mapError(err)
+}
+
+// DeleteArticle deletes the article along with all of its comments in a single transaction
+// returns ErrRecordNotFound if there's no article with the provided id
+func (repo *Repository) DeleteArticle(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM comment WHERE article_id = $1", id); err != nil {
+		return mapError(err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM article WHERE id = $1", id)
+	if err != nil {
+		return mapError(err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return mapError(err)
+	} else if affected == 0 {
+		return ErrRecordNotFound
+	}
+	return mapError(tx.Commit())
+}
+
+// GetArticleRevisions returns up to limit revisions of the article, the latest first, starting right before the revision
+// before if it's positive
+func (repo *Repository) GetArticleRevisions(ctx context.Context, articleId int, limit int, before int) ([]models.ArticleRevision, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT article_id, revision, title, content, tags, status, creation_timestamp FROM article_revision "+
+		"WHERE article_id = $1 AND ($2 <= 0 OR revision < $2) ORDER BY revision DESC LIMIT $3", articleId, before, limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	revisions, err := scanRevisions(rows)
+	return revisions, mapError(err)
+}
+
+// GetArticleRevision returns ErrRecordNotFound if the article doesn't have the revision
+func (repo *Repository) GetArticleRevision(ctx context.Context, articleId int, revision int) (*models.ArticleRevision, error) {
+	result := new(models.ArticleRevision)
+	var tags sql.NullString
+	row := repo.db.QueryRowContext(ctx, "SELECT article_id, revision, title, content, tags, status, creation_timestamp FROM article_revision "+
+		"WHERE article_id = $1 AND revision = $2", articleId, revision)
+	err := row.Scan(&result.ArticleId, &result.Revision, &result.Title, &result.Content, &tags, &result.Status, &result.CreationTimestamp)
+	result.Tags = splitTags(tags)
+	return result, mapError(err)
+}
+
+func (repo *Repository) GetCommentById(ctx context.Context, id int) (*models.Comment, error) {
+	comment := new(models.Comment)
+	result := repo.db.QueryRowContext(ctx, "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment WHERE id = $1", id)
+	err := result.Scan(&comment.Id, &comment.ArticleId, &comment.ParentId, &comment.Author, &comment.Content, &comment.CreationTimestamp)
+	return comment, mapError(err)
+}
+
+// CreateComment inserts the comment and fills its generated id and stored creation_timestamp
+func (repo *Repository) CreateComment(ctx context.Context, comment *models.Comment) error {
 	if comment.CreationTimestamp.IsZero() {
 		comment.CreationTimestamp = time.Now()
 	}
-	_, err := repo.db.Exec("INSERT INTO comment(article_id, author, content, creation_timestamp) VALUES ($1, $2, $3, $4)",
-		comment.ArticleId, comment.Author, comment.Content, comment.CreationTimestamp)
-	if pgerr, ok := err.(*pgconn.PgError); ok {
-		if pgerr.Code == "23503" { // FOREIGN KEY VIOLATION code in postgres
-			return errors.New(ArticleIdFKErrorContent)
+	result := repo.db.QueryRowContext(ctx, "INSERT INTO comment(article_id, parent_id, author, content, creation_timestamp) VALUES ($1, $2, $3, $4, $5) "+
+		"RETURNING id, creation_timestamp", comment.ArticleId, comment.ParentId, comment.Author, comment.Content, comment.CreationTimestamp)
+	return mapError(result.Scan(&comment.Id, &comment.CreationTimestamp))
+}
+
+// GetCommentsByArticleId returns all the comments of the article ordered by creation_timestamp then id
+func (repo *Repository) GetCommentsByArticleId(ctx context.Context, articleId int) ([]models.Comment, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment "+
+		"WHERE article_id = $1 ORDER BY creation_timestamp, id", articleId)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	comments, err := scanComments(rows)
+	return comments, mapError(err)
+}
+
+// GetCommentsPage returns up to limit comments of the article in the requested order,
+// starting right after the cursor if provided
+func (repo *Repository) GetCommentsPage(ctx context.Context, articleId int, sort models.CommentSort, limit int, after *pagination.Cursor) ([]models.Comment, error) {
+	order, comparison := commentsOrder(sort)
+	query := "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment WHERE article_id = $1"
+	args := []any{articleId}
+	if after != nil {
+		query += " AND (creation_timestamp, id) " + comparison + " ($2, $3)"
+		args = append(args, after.Timestamp, after.Id)
+	}
+	query += fmt.Sprintf(" ORDER BY creation_timestamp %[1]s, id %[1]s LIMIT $%d", order, len(args)+1)
+	rows, err := repo.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	comments, err := scanComments(rows)
+	return comments, mapError(err)
+}
+
+// commentsOrder returns the SQL order of the sort and the comparison that selects the rows after a cursor in that order
+func commentsOrder(sort models.CommentSort) (order string, comparison string) {
+	if sort == models.CommentSortNewest {
+		return "DESC", "<"
+	}
+	return "ASC", ">"
+}
+
+// articleFilterConditions returns the SQL conditions selecting the articles matching the filter, each prefixed with AND,
+// and args with the conditions' args appended, placeholder returns the placeholder of the nth arg
+// so the conditions can be shared by the Postgres and SQLite repositories
+func articleFilterConditions(filter models.ArticleFilter, placeholder func(n int) string, args []any) (string, []any) {
+	conditions := ""
+	if filter.Status != "" {
+		args = append(args, string(filter.Status))
+		conditions += " AND status = " + placeholder(len(args))
+	}
+	if len(filter.Tags) == 0 {
+		return conditions, args
+	}
+	placeholders := make([]string, len(filter.Tags))
+	for i, tag := range filter.Tags {
+		args = append(args, tag)
+		placeholders[i] = placeholder(len(args))
+	}
+	matches := "FROM article_tag JOIN tag ON tag.id = article_tag.tag_id " +
+		"WHERE article_tag.article_id = article.id AND tag.name IN (" + strings.Join(placeholders, ", ") + ")"
+	if filter.MatchAllTags {
+		return conditions + fmt.Sprintf(" AND (SELECT COUNT(*) %s) = %d", matches, len(filter.Tags)), args
+	}
+	return conditions + " AND EXISTS (SELECT 1 " + matches + ")", args
+}
+
+// slugOwner is the article a slug leads to and the base slug it was made out of
+type slugOwner struct {
+	articleId int
+	base      string
+}
+
+// freeSlug returns the slug of the article for the base slug, owners maps the taken slugs to their owners
+// the current slug is kept if it's the base, or if the base is taken by another article and the current slug is the article's
+// collision suffix of the base. Otherwise it's the first of base, base-2, base-3... that isn't taken by another article
+func freeSlug(base string, current string, articleId int, owners map[string]slugOwner) string {
+	if current == base {
+		return current
+	}
+	if owner, taken := owners[base]; taken && owner.articleId != articleId && owners[current] == (slugOwner{articleId, base}) {
+		return current
+	}
+	candidate := base
+	for n := 2; ; n++ {
+		if owner, taken := owners[candidate]; !taken || owner.articleId == articleId {
+			return candidate
 		}
+		candidate = base + "-" + strconv.Itoa(n)
 	}
-	return err
 }
 
-func (repo *Repository) GetCommentsByArticleId(articleId int) ([]models.Comment, error) {
+// scanSlugOwners maps the scanned slugs to their owners, the rows must have the slug, article_id and base columns
+func scanSlugOwners(rows *sql.Rows) (map[string]slugOwner, error) {
+	defer rows.Close()
+	owners := map[string]slugOwner{}
+	for rows.Next() {
+		var slug string
+		var owner slugOwner
+		if err := rows.Scan(&slug, &owner.articleId, &owner.base); err != nil {
+			return nil, err
+		}
+		owners[slug] = owner
+	}
+	return owners, rows.Err()
+}
+
+// scanIds returns the ids of the rows sorted, the rows must have a single id column
+func scanIds(rows *sql.Rows) ([]int, error) {
+	defer rows.Close()
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, rows.Err()
+}
+
+func postgresPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// splitTags parses the comma separated tags selected by articleTagsColumn, tags can't contain commas
+// they're sorted as the aggregation order isn't guaranteed
+func splitTags(tags sql.NullString) []string {
+	if !tags.Valid || tags.String == "" {
+		return []string{}
+	}
+	result := strings.Split(tags.String, ",")
+	slices.Sort(result)
+	return result
+}
+
+// scanTagCounts reads and closes the rows of a tags query, errors are returned as is for the caller to map
+func scanTagCounts(rows *sql.Rows) ([]models.TagCount, error) {
+	defer rows.Close()
+	result := []models.TagCount{}
+	for rows.Next() {
+		tag := models.TagCount{}
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, err
+		}
+		result = append(result, tag)
+	}
+	return result, rows.Err()
+}
+
+// scanRevisions reads and closes the rows of a revision query, errors are returned as is for the caller to map
+func scanRevisions(rows *sql.Rows) ([]models.ArticleRevision, error) {
+	defer rows.Close()
+	result := []models.ArticleRevision{}
+	for rows.Next() {
+		revision := new(models.ArticleRevision)
+		var tags sql.NullString
+		if err := rows.Scan(&revision.ArticleId, &revision.Revision, &revision.Title, &revision.Content, &tags, &revision.Status,
+			&revision.CreationTimestamp); err != nil {
+			return nil, err
+		}
+		revision.Tags = splitTags(tags)
+		result = append(result, *revision)
+	}
+	return result, rows.Err()
+}
+
+// scanComments reads and closes the rows of a comment query, errors are returned as is for the caller to map
+func scanComments(rows *sql.Rows) ([]models.Comment, error) {
+	defer rows.Close()
 	result := []models.Comment{}
-	rows, err := repo.db.Query("SELECT id, article_id, author, content, creation_timestamp FROM comment")
 	for rows.Next() {
 		comment := new(models.Comment)
-		rows.Scan(&comment.Id, &comment.ArticleId, &comment.Author, &comment.Content, &comment.CreationTimestamp)
+		if err := rows.Scan(&comment.Id, &comment.ArticleId, &comment.ParentId, &comment.Author, &comment.Content, &comment.CreationTimestamp); err != nil {
+			return nil, err
+		}
 		result = append(result, *comment)
 	}
-	return result, err
+	return result, rows.Err()
+}
+
+// mapError maps the database errors to the repository's domain errors, unknown errors are returned as is
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecordNotFound.Wrap(err)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23503": // foreign_key_violation
+			return ErrForeignKeyViolation.Wrap(err)
+		case pgErr.Code == "23505": // unique_violation
+			return ErrUniqueViolation.Wrap(err)
+		case pgErr.Code == "23502" || pgErr.Code == "23514" || pgErr.Code == "22001": // not_null_violation, check_violation, string_data_right_truncation
+			return ErrConstraintViolation.Wrap(err)
+		case strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "57P") || pgErr.Code == "53300": // connection exceptions, shutdowns, too_many_connections
+			return ErrDatabaseUnavailable.Wrap(err)
+		}
+		return err
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return ErrDatabaseUnavailable.Wrap(err)
+	}
+	return err
 }
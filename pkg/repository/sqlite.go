@@ -0,0 +1,436 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/pagination"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SqliteRepository implements all the repository interfaces on top of SQLite
+// The db must be opened with foreign keys enabled, see SqliteDsn
+type SqliteRepository struct {
+	db *tracing.DB
+}
+
+func NewSqliteRepository(db *sql.DB) *SqliteRepository {
+	return &SqliteRepository{db: tracing.WrapDB(db, semconv.DBSystemSqlite)}
+}
+
+// SqliteDsn returns the data source name to open the SQLite database file at path with the pragmas the repository relies on
+func SqliteDsn(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+}
+
+// sqliteArticleTagsColumn selects the tags of the article as a comma separated list, see splitTags
+const sqliteArticleTagsColumn = "(SELECT group_concat(tag.name, ',') FROM article_tag JOIN tag ON tag.id = article_tag.tag_id " +
+	"WHERE article_tag.article_id = article.id) AS tags"
+
+func (repo *SqliteRepository) GetArticleById(ctx context.Context, id int) (*models.Article, error) {
+	article := new(models.Article)
+	var tags sql.NullString
+	result := repo.db.QueryRowContext(ctx, "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, "+sqliteArticleTagsColumn+" FROM article WHERE id = ?", id)
+	err := result.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags)
+	article.Tags = splitTags(tags)
+	return article, mapSqliteError(err)
+}
+
+// GetArticleBySlug returns the article having the slug either as its current slug or as one of its previous ones
+func (repo *SqliteRepository) GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error) {
+	article := new(models.Article)
+	var tags sql.NullString
+	result := repo.db.QueryRowContext(ctx, "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, "+sqliteArticleTagsColumn+
+		" FROM article WHERE id = (SELECT article_id FROM article_slug WHERE slug = ?)", slug)
+	err := result.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags)
+	article.Tags = splitTags(tags)
+	return article, mapSqliteError(err)
+}
+
+// GetArticles returns up to limit articles matching the filter ordered by creation_timestamp then id,
+// starting right after the cursor if provided
+func (repo *SqliteRepository) GetArticles(ctx context.Context, limit int, after *pagination.Cursor, filter models.ArticleFilter) ([]models.Article, error) {
+	query := "SELECT id, COALESCE(slug, ''), title, content, status, publish_at, creation_timestamp, " + sqliteArticleTagsColumn + " FROM article WHERE TRUE"
+	args := []any{}
+	if after != nil {
+		query += " AND (creation_timestamp, id) > (?, ?)"
+		args = append(args, sqliteTime(after.Timestamp), after.Id)
+	}
+	conditions, args := articleFilterConditions(filter, sqlitePlaceholder, args)
+	query += conditions + " ORDER BY creation_timestamp, id LIMIT ?"
+	rows, err := repo.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	defer rows.Close()
+	result := []models.Article{}
+	for rows.Next() {
+		article := new(models.Article)
+		var tags sql.NullString
+		if err = rows.Scan(&article.Id, &article.Slug, &article.Title, &article.Content, &article.Status, &article.PublishAt, &article.CreationTimestamp, &tags); err != nil {
+			return nil, mapSqliteError(err)
+		}
+		article.Tags = splitTags(tags)
+		result = append(result, *article)
+	}
+	return result, mapSqliteError(rows.Err())
+}
+
+// SearchArticles runs an FTS5 search over titles and contents of the articles having the status (any status if it's empty),
+// the bm25 rank is negated so higher is more relevant like the Postgres search, and every word of the query must be present in the article
+func (repo *SqliteRepository) SearchArticles(ctx context.Context, query string, status models.ArticleStatus, limit int, offset int) ([]models.ArticleSearchResult, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT article.id, COALESCE(article.slug, ''), article.title, article.content, article.status, article.publish_at, article.creation_timestamp, `+sqliteArticleTagsColumn+`,
+			-bm25(article_fts, 10.0, 1.0) AS rank,
+			snippet(article_fts, 1, '<mark>', '</mark>', '...', 30)
+		FROM article_fts JOIN article ON article.id = article_fts.rowid
+		WHERE article_fts MATCH ?1 AND (?2 = '' OR article.status = ?2)
+		ORDER BY rank DESC, article.id
+		LIMIT ?3 OFFSET ?4`, ftsQuery(query), string(status), limit, offset)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	defer rows.Close()
+	result := []models.ArticleSearchResult{}
+	for rows.Next() {
+		r := new(models.ArticleSearchResult)
+		var tags sql.NullString
+		if err = rows.Scan(&r.Id, &r.Slug, &r.Title, &r.Content, &r.Status, &r.PublishAt, &r.CreationTimestamp, &tags, &r.Rank, &r.Snippet); err != nil {
+			return nil, mapSqliteError(err)
+		}
+		r.Tags = splitTags(tags)
+		result = append(result, *r)
+	}
+	return result, mapSqliteError(rows.Err())
+}
+
+// CreateArticle inserts the article with its tags as its first revision and fills its generated id, slug and stored creation_timestamp
+// its publish_at is only kept if it's scheduled, see createdPublishAt
+func (repo *SqliteRepository) CreateArticle(ctx context.Context, article *models.Article) error {
+	if article.CreationTimestamp.IsZero() {
+		article.CreationTimestamp = time.Now()
+	}
+	article.PublishAt = createdPublishAt(article)
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	defer tx.Rollback()
+	result := tx.QueryRowContext(ctx, "INSERT INTO article(title, content, status, publish_at, creation_timestamp) VALUES (?, ?, ?, ?, ?) "+
+		"RETURNING id, creation_timestamp", article.Title, article.Content, string(article.Status), sqliteNullTime(article.PublishAt),
+		sqliteTime(article.CreationTimestamp))
+	if err = result.Scan(&article.Id, &article.CreationTimestamp); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = setSqliteArticleSlug(ctx, tx, article); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = setSqliteArticleTags(ctx, tx, article.Id, article.Tags); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = recordSqliteRevision(ctx, tx, article.Id); err != nil {
+		return mapSqliteError(err)
+	}
+	return mapSqliteError(tx.Commit())
+}
+
+func (repo *SqliteRepository) UpdateArticle(ctx context.Context, article *models.Article) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	defer tx.Rollback()
+	result := tx.QueryRowContext(ctx, "UPDATE article SET title = ?, content = ? WHERE id = ? RETURNING status, publish_at, creation_timestamp",
+		article.Title, article.Content, article.Id)
+	if err = result.Scan(&article.Status, &article.PublishAt, &article.CreationTimestamp); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = setSqliteArticleSlug(ctx, tx, article); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = setSqliteArticleTags(ctx, tx, article.Id, article.Tags); err != nil {
+		return mapSqliteError(err)
+	}
+	if err = recordSqliteRevision(ctx, tx, article.Id); err != nil {
+		return mapSqliteError(err)
+	}
+	return mapSqliteError(tx.Commit())
+}
+
+// SetArticleStatus changes the status and publish_at of the article only if it's still in the from status and records it
+// as a new revision, returns ErrRecordNotFound if there's no article with the provided id in that status
+func (repo *SqliteRepository) SetArticleStatus(ctx context.Context, id int, from models.ArticleStatus, to models.ArticleStatus, publishAt *time.Time) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, "UPDATE article SET status = ?, publish_at = ? WHERE id = ? AND status = ?",
+		string(to), sqliteNullTime(publishAt), id, string(from))
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return mapSqliteError(err)
+	} else if affected == 0 {
+		return ErrRecordNotFound
+	}
+	if err = recordSqliteRevision(ctx, tx, id); err != nil {
+		return mapSqliteError(err)
+	}
+	return mapSqliteError(tx.Commit())
+}
+
+// PublishDueArticles publishes up to limit scheduled articles whose publish_at is before now, the earliest first,
+// and returns their ids, each of them gets a new revision. SQLite has no row locks but a single writer,
+// so the update is enough to publish every article once
+func (repo *SqliteRepository) PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	defer tx.Rollback()
+	rows, err := tx.QueryContext(ctx, `UPDATE article SET status = 'published', publish_at = NULL
+		WHERE id IN (
+			SELECT id FROM article WHERE status = 'scheduled' AND publish_at <= ?
+			ORDER BY publish_at, id LIMIT ?
+		)
+		RETURNING id`, sqliteTime(now), limit)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	ids, err := scanIds(rows)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	for _, id := range ids {
+		if err = recordSqliteRevision(ctx, tx, id); err != nil {
+			return nil, mapSqliteError(err)
+		}
+	}
+	return ids, mapSqliteError(tx.Commit())
+}
+
+// recordSqliteRevision snapshots the article as its next revision, it must run in the transaction that changed the article
+func recordSqliteRevision(ctx context.Context, tx *tracing.Tx, articleId int) error {
+	_, err := tx.ExecContext(ctx, `INSERT INTO article_revision(article_id, revision, title, content, tags, status, creation_timestamp)
+		SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM article_revision WHERE article_id = article.id),
+			title, content, `+sqliteArticleTagsColumn+`, status, ?2
+		FROM article WHERE id = ?1`, articleId, sqliteTime(time.Now()))
+	return err
+}
+
+// setSqliteArticleSlug replaces the slug of the article with a free slug made out of the base slug in article.Slug, see freeSlug,
+// its previous slugs are kept so they keep leading to it. An empty base keeps the current slug
+// It must run after the transaction wrote to the article so it holds the database's write lock while picking the slug
+func setSqliteArticleSlug(ctx context.Context, tx *tracing.Tx, article *models.Article) error {
+	base := article.Slug
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(slug, '') FROM article WHERE id = ?", article.Id).Scan(&article.Slug); err != nil {
+		return err
+	}
+	if base == "" {
+		return nil
+	}
+	rows, err := tx.QueryContext(ctx, "SELECT slug, article_id, base FROM article_slug WHERE slug = ? OR slug LIKE ?", base, base+"-%")
+	if err != nil {
+		return err
+	}
+	owners, err := scanSlugOwners(rows)
+	if err != nil {
+		return err
+	}
+	article.Slug = freeSlug(base, article.Slug, article.Id, owners)
+	if owners[article.Slug].articleId == article.Id {
+		_, err = tx.ExecContext(ctx, "UPDATE article_slug SET base = ? WHERE slug = ?", base, article.Slug)
+	} else {
+		_, err = tx.ExecContext(ctx, "INSERT INTO article_slug(slug, article_id, base, creation_timestamp) VALUES (?, ?, ?, ?)",
+			article.Slug, article.Id, base, sqliteTime(time.Now()))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE article SET slug = ? WHERE id = ?", article.Slug, article.Id)
+	return err
+}
+
+// setSqliteArticleTags replaces the tags of the article, the tags that don't exist yet are created
+func setSqliteArticleTags(ctx context.Context, tx *tracing.Tx, articleId int, tags []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM article_tag WHERE article_id = ?", articleId); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO tag(name) VALUES (?) ON CONFLICT (name) DO NOTHING", tag); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO article_tag(article_id, tag_id) SELECT ?, id FROM tag WHERE name = ?",
+			articleId, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTags returns the tags used by at least one article having the status (any status if it's empty)
+// with their number of articles, the most used first
+func (repo *SqliteRepository) GetTags(ctx context.Context, status models.ArticleStatus) ([]models.TagCount, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT tag.name, COUNT(*) FROM tag JOIN article_tag ON article_tag.tag_id = tag.id "+
+		"JOIN article ON article.id = article_tag.article_id WHERE ?1 = '' OR article.status = ?1 "+
+		"GROUP BY tag.name ORDER BY COUNT(*) DESC, tag.name", string(status))
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	tags, err := scanTagCounts(rows)
+	return tags, mapSqliteError(err)
+}
+
+func (repo *SqliteRepository) DeleteArticle(ctx context.Context, id int) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.ExecContext(ctx, "DELETE FROM comment WHERE article_id = ?", id); err != nil {
+		return mapSqliteError(err)
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM article WHERE id = ?", id)
+	if err != nil {
+		return mapSqliteError(err)
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return mapSqliteError(err)
+	} else if affected == 0 {
+		return ErrRecordNotFound
+	}
+	return mapSqliteError(tx.Commit())
+}
+
+// GetArticleRevisions returns up to limit revisions of the article, the latest first, starting right before the revision
+// before if it's positive
+func (repo *SqliteRepository) GetArticleRevisions(ctx context.Context, articleId int, limit int, before int) ([]models.ArticleRevision, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT article_id, revision, title, content, tags, status, creation_timestamp FROM article_revision "+
+		"WHERE article_id = ?1 AND (?2 <= 0 OR revision < ?2) ORDER BY revision DESC LIMIT ?3", articleId, before, limit)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	revisions, err := scanRevisions(rows)
+	return revisions, mapSqliteError(err)
+}
+
+// GetArticleRevision returns ErrRecordNotFound if the article doesn't have the revision
+func (repo *SqliteRepository) GetArticleRevision(ctx context.Context, articleId int, revision int) (*models.ArticleRevision, error) {
+	result := new(models.ArticleRevision)
+	var tags sql.NullString
+	row := repo.db.QueryRowContext(ctx, "SELECT article_id, revision, title, content, tags, status, creation_timestamp FROM article_revision "+
+		"WHERE article_id = ? AND revision = ?", articleId, revision)
+	err := row.Scan(&result.ArticleId, &result.Revision, &result.Title, &result.Content, &tags, &result.Status, &result.CreationTimestamp)
+	result.Tags = splitTags(tags)
+	return result, mapSqliteError(err)
+}
+
+func (repo *SqliteRepository) GetCommentById(ctx context.Context, id int) (*models.Comment, error) {
+	comment := new(models.Comment)
+	result := repo.db.QueryRowContext(ctx, "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment WHERE id = ?", id)
+	err := result.Scan(&comment.Id, &comment.ArticleId, &comment.ParentId, &comment.Author, &comment.Content, &comment.CreationTimestamp)
+	return comment, mapSqliteError(err)
+}
+
+// CreateComment inserts the comment and fills its generated id and stored creation_timestamp
+func (repo *SqliteRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	if comment.CreationTimestamp.IsZero() {
+		comment.CreationTimestamp = time.Now()
+	}
+	result := repo.db.QueryRowContext(ctx, "INSERT INTO comment(article_id, parent_id, author, content, creation_timestamp) VALUES (?, ?, ?, ?, ?) "+
+		"RETURNING id, creation_timestamp", comment.ArticleId, comment.ParentId, comment.Author, comment.Content, sqliteTime(comment.CreationTimestamp))
+	return mapSqliteError(result.Scan(&comment.Id, &comment.CreationTimestamp))
+}
+
+func (repo *SqliteRepository) GetCommentsByArticleId(ctx context.Context, articleId int) ([]models.Comment, error) {
+	rows, err := repo.db.QueryContext(ctx, "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment "+
+		"WHERE article_id = ? ORDER BY creation_timestamp, id", articleId)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	comments, err := scanComments(rows)
+	return comments, mapSqliteError(err)
+}
+
+// GetCommentsPage returns up to limit comments of the article in the requested order,
+// starting right after the cursor if provided
+func (repo *SqliteRepository) GetCommentsPage(ctx context.Context, articleId int, sort models.CommentSort, limit int, after *pagination.Cursor) ([]models.Comment, error) {
+	order, comparison := commentsOrder(sort)
+	query := "SELECT id, article_id, parent_id, author, content, creation_timestamp FROM comment WHERE article_id = ?"
+	args := []any{articleId}
+	if after != nil {
+		query += " AND (creation_timestamp, id) " + comparison + " (?, ?)"
+		args = append(args, sqliteTime(after.Timestamp), after.Id)
+	}
+	query += fmt.Sprintf(" ORDER BY creation_timestamp %[1]s, id %[1]s LIMIT ?", order)
+	rows, err := repo.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, mapSqliteError(err)
+	}
+	comments, err := scanComments(rows)
+	return comments, mapSqliteError(err)
+}
+
+func sqlitePlaceholder(int) string {
+	return "?"
+}
+
+// sqliteTime normalizes timestamps to UTC with microsecond precision like Postgres' TIMESTAMP,
+// SQLite stores them as text so they must share the same zone to be ordered correctly
+func sqliteTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// sqliteNullTime normalizes the timestamp like sqliteTime, nil is kept as NULL
+func sqliteNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return sqliteTime(*t)
+}
+
+// ftsQuery quotes every word of the query so it's matched as is rather than parsed as FTS5 query syntax
+func ftsQuery(query string) string {
+	terms := strings.Fields(query)
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// mapSqliteError maps the SQLite errors to the repository's domain errors, unknown errors are returned as is
+func mapSqliteError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecordNotFound.Wrap(err)
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); {
+		case code == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			return ErrForeignKeyViolation.Wrap(err)
+		case code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			return ErrUniqueViolation.Wrap(err)
+		case code == sqlite3.SQLITE_CONSTRAINT_NOTNULL || code == sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return ErrConstraintViolation.Wrap(err)
+		case code&0xff == sqlite3.SQLITE_BUSY || code&0xff == sqlite3.SQLITE_LOCKED || code&0xff == sqlite3.SQLITE_CANTOPEN:
+			return ErrDatabaseUnavailable.Wrap(err)
+		}
+		return err
+	}
+	if errors.Is(err, sql.ErrConnDone) {
+		return ErrDatabaseUnavailable.Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSqliteRepositoryContract(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Storage {
+		db, err := sql.Open("sqlite", SqliteDsn(filepath.Join(t.TempDir(), "articles.db")))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migrations.New(ctx, db, migrations.Sqlite, "")
+		require.NoError(t, err)
+		defer migrator.Close()
+		require.NoError(t, migrator.Up())
+		return NewSqliteRepository(db)
+	})
+}
@@ -0,0 +1,209 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/metrics"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+/*
+ * The scheduler publishes the scheduled articles once their publish_at is due. Every replica runs its own scheduler,
+ * the repositories lock the due articles so each one is published by a single replica, and runs never overlap
+ * within a replica whether they're triggered by the interval or manually
+ */
+
+const (
+	DefaultInterval  = 30 * time.Second
+	DefaultBatchSize = 100
+)
+
+// The triggers of a run
+const (
+	TriggerInterval = "interval"
+	TriggerManual   = "manual"
+)
+
+// Publisher publishes up to limit scheduled articles that are due at now and returns their ids
+type Publisher interface {
+	PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error)
+}
+
+// Run is the outcome of a single run, Published holds the ids of the articles it published
+type Run struct {
+	Trigger    string    `json:"trigger"`
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs float64   `json:"duration_ms"`
+	Published  []int     `json:"published"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// State is a snapshot of the scheduler, Running tells whether the interval runs are on
+// LastRun is nil until the first run and NextRunAt is nil when the interval runs are off
+type State struct {
+	Running        bool       `json:"running"`
+	Interval       string     `json:"interval"`
+	BatchSize      int        `json:"batch_size"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRun        *Run       `json:"last_run,omitempty"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	Runs           int        `json:"runs"`
+	Failures       int        `json:"failures"`
+	PublishedTotal int        `json:"published_total"`
+}
+
+type Scheduler struct {
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+
+	runMu sync.Mutex // held during a run so runs never overlap
+	mu    sync.Mutex // guards the fields below
+	state State
+	stop  context.CancelFunc
+	done  chan struct{}
+}
+
+// New creates a stopped scheduler that publishes up to batchSize articles at a time every interval,
+// interval and batchSize fall back to DefaultInterval and DefaultBatchSize if they're not positive
+func New(publisher Publisher, interval time.Duration, batchSize int) *Scheduler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	return &Scheduler{
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+		state:     State{Interval: interval.String(), BatchSize: batchSize},
+	}
+}
+
+// Start runs the scheduler every interval in a goroutine until Shutdown is called, the first run is right away
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	s.stop, s.done = stop, make(chan struct{})
+	s.state.Running = true
+	go s.loop(ctx, s.done)
+}
+
+// Shutdown stops the interval runs, the current one is canceled, and waits for the goroutine to return
+// it returns ctx's error if ctx is done first
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.state.Running, s.state.NextRunAt = false, nil
+	s.mu.Unlock()
+	if stop == nil {
+		return nil
+	}
+	stop()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RunNow runs the scheduler right away and returns the run, it waits for the current run if there's one
+// a failed run is returned along with its error
+func (s *Scheduler) RunNow(ctx context.Context) (*Run, error) {
+	return s.run(ctx, TriggerManual)
+}
+
+// State returns a snapshot of the scheduler
+func (s *Scheduler) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state
+	if state.LastRun != nil {
+		lastRun := *state.LastRun
+		state.LastRun = &lastRun
+	}
+	return state
+}
+
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.run(ctx, TriggerInterval)
+		s.mu.Lock()
+		if s.state.Running {
+			next := time.Now().Add(s.interval)
+			s.state.NextRunAt = &next
+		}
+		s.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run publishes the due articles batch by batch until there are no more, the failures are logged and kept in the state
+func (s *Scheduler) run(ctx context.Context, trigger string) (run *Run, err error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	ctx, span := tracing.Start(ctx, "scheduler.Run", attribute.String("scheduler.trigger", trigger))
+	defer func() { tracing.End(span, err) }()
+
+	run = &Run{Trigger: trigger, StartedAt: time.Now().UTC(), Published: []int{}}
+	for {
+		var published []int
+		published, err = s.publisher.PublishDueArticles(ctx, time.Now().UTC(), s.batchSize)
+		run.Published = append(run.Published, published...)
+		if err != nil || len(published) < s.batchSize {
+			break
+		}
+	}
+	run.DurationMs = float64(time.Since(run.StartedAt).Microseconds()) / 1000
+	span.SetAttributes(attribute.Int("scheduler.published", len(run.Published)))
+	if err != nil {
+		err = fmt.Errorf("publishing the due articles: %w", err)
+		run.Error = err.Error()
+	}
+	s.record(ctx, run)
+	return run, err
+}
+
+// record keeps the run in the state, and updates the metrics and logs it
+func (s *Scheduler) record(ctx context.Context, run *Run) {
+	logger := logging.For(ctx, "scheduler")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.LastRun = run
+	s.state.Runs++
+	s.state.PublishedTotal += len(run.Published)
+	metrics.ArticlesPublishedByScheduler.Add(float64(len(run.Published)))
+	if run.Error != "" {
+		s.state.Failures++
+		metrics.SchedulerRuns.WithLabelValues(run.Trigger, "failure").Inc()
+		logger.Error("Publishing the scheduled articles failed", "trigger", run.Trigger, "error", run.Error,
+			"published", len(run.Published))
+		return
+	}
+	finishedAt := time.Now().UTC()
+	s.state.LastSuccessAt = &finishedAt
+	metrics.SchedulerRuns.WithLabelValues(run.Trigger, "success").Inc()
+	metrics.SchedulerLastSuccess.Set(float64(finishedAt.Unix()))
+	if len(run.Published) > 0 || run.Trigger == TriggerManual {
+		logger.Info("Scheduled articles published", "trigger", run.Trigger, "article_ids", run.Published)
+	}
+}
@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) PublishDueArticles(ctx context.Context, now time.Time, limit int) ([]int, error) {
+	return nil, errors.New("the database is down")
+}
+
+func TestRunNowShouldPublishAllTheDueArticlesInBatches(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	due := []int{
+		scheduleArticle(t, repo, time.Now().Add(-time.Hour)),
+		scheduleArticle(t, repo, time.Now().Add(-time.Minute)),
+		scheduleArticle(t, repo, time.Now().Add(-time.Second)),
+	}
+	notDue := scheduleArticle(t, repo, time.Now().Add(time.Hour))
+	scheduler := New(repo, time.Minute, 2)
+
+	// When
+	run, err := scheduler.RunNow(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	assert.ElementsMatch(t, due, run.Published, "All the due articles must be published even if they don't fit in a batch")
+	article, err := repo.GetArticleById(context.Background(), notDue)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusScheduled, article.Status)
+	state := scheduler.State()
+	assert.Equal(t, 1, state.Runs)
+	assert.Equal(t, 3, state.PublishedTotal)
+	assert.NotNil(t, state.LastSuccessAt)
+}
+
+func TestRunNowShouldRecordFailures(t *testing.T) {
+	// Given
+	scheduler := New(failingPublisher{}, time.Minute, 10)
+
+	// When
+	run, err := scheduler.RunNow(context.Background())
+
+	// Then
+	assert.ErrorContains(t, err, "the database is down")
+	assert.Equal(t, err.Error(), run.Error)
+	state := scheduler.State()
+	assert.Equal(t, 1, state.Failures)
+	assert.Nil(t, state.LastSuccessAt)
+	assert.Equal(t, err.Error(), state.LastRun.Error)
+}
+
+func TestStartAndShutdown(t *testing.T) {
+	// Given
+	repo := repository.NewMemoryRepository()
+	due := scheduleArticle(t, repo, time.Now().Add(-time.Minute))
+	scheduler := New(repo, time.Hour, 10)
+
+	// When
+	scheduler.Start()
+
+	// Then the first run is right away
+	assert.Eventually(t, func() bool {
+		article, err := repo.GetArticleById(context.Background(), due)
+		return err == nil && article.Status == models.StatusPublished
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return scheduler.State().NextRunAt != nil }, time.Second, 10*time.Millisecond)
+	assert.True(t, scheduler.State().Running)
+
+	// When
+	require.NoError(t, scheduler.Shutdown(context.Background()))
+
+	// Then
+	state := scheduler.State()
+	assert.False(t, state.Running)
+	assert.Nil(t, state.NextRunAt)
+	assert.NoError(t, scheduler.Shutdown(context.Background()), "Shutting down twice must be a no-op")
+}
+
+func scheduleArticle(t *testing.T, repo *repository.MemoryRepository, publishAt time.Time) int {
+	article := &models.Article{Title: "Awesome Go", Content: "Awesome", Status: models.StatusScheduled, PublishAt: &publishAt}
+	require.NoError(t, repo.CreateArticle(context.Background(), article))
+	return article.Id
+}
@@ -0,0 +1,94 @@
+// Package slug turns titles into readable URL-safe slugs
+package slug
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the max length of the slugs made by Make, it leaves room for the collision suffixes
+// within the 255 characters of the database column
+const MaxLength = 100
+
+// Fallback is the slug of the titles without any letter or digit that can be transliterated
+const Fallback = "article"
+
+// transliterations holds the lowercase letters that don't decompose to ASCII, an empty string drops the letter
+// without separating the words around it like the apostrophes in "don't"
+var transliterations = map[rune]string{
+	'\'': "", '’': "",
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i", 'ħ': "h", 'ŧ': "t",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh", 'з': "z", 'и': "i", 'й': "y",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f",
+	'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l",
+	'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f",
+	'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Make returns the slug of the title, its letters transliterated to lowercase ASCII and its digits with a single hyphen
+// between words, e.g. "Crème Brûlée: 10 recipes!" becomes "creme-brulee-10-recipes"
+// slugs longer than MaxLength are cut at the last word that fits, and Fallback is returned if nothing is left
+func Make(title string) string {
+	var builder strings.Builder
+	separated := false
+	for _, r := range title {
+		ascii, known := transliterate(unicode.ToLower(r))
+		switch {
+		case !known:
+			separated = true
+		case ascii != "":
+			if separated && builder.Len() > 0 {
+				builder.WriteByte('-')
+			}
+			separated = false
+			builder.WriteString(ascii)
+		}
+	}
+	slug := builder.String()
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength+1]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		} else {
+			slug = slug[:MaxLength]
+		}
+	}
+	if slug == "" {
+		return Fallback
+	}
+	return slug
+}
+
+// transliterate returns the ASCII letters and digits of the lowercase rune and whether it has any,
+// the runes that aren't in transliterations are decomposed and their accents are dropped
+func transliterate(r rune) (string, bool) {
+	if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+		return string(r), true
+	}
+	if ascii, ok := transliterations[r]; ok {
+		return ascii, true
+	}
+	decomposed := norm.NFKD.String(string(r))
+	if decomposed == string(r) {
+		return "", false
+	}
+	var builder strings.Builder
+	known := false
+	for _, d := range decomposed {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		if ascii, ok := transliterate(unicode.ToLower(d)); ok {
+			builder.WriteString(ascii)
+			known = true
+		}
+	}
+	return builder.String(), known
+}
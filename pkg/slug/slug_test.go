@@ -0,0 +1,44 @@
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	for _, test := range []struct {
+		title    string
+		expected string
+	}{
+		{"Awesome Go", "awesome-go"},
+		{"  Go 1.23: what's new?! ", "go-1-23-whats-new"},
+		{"Crème Brûlée: 10 recipes!", "creme-brulee-10-recipes"},
+		{"Straße & Ærø", "strasse-aero"},
+		{"Привет, мир", "privet-mir"},
+		{"Объект", "obekt"},
+		{"Καλημέρα κόσμε", "kalimera-kosme"},
+		{"ﬁle №1", "file-no1"},
+		{"C++ & C#", "c-c"},
+		{"日本語", Fallback},
+		{"!!!", Fallback},
+	} {
+		t.Run(test.title, func(t *testing.T) {
+			assert.Equal(t, test.expected, Make(test.title))
+		})
+	}
+}
+
+func TestMakeShouldCutLongSlugsAtAWord(t *testing.T) {
+	// Given
+	title := strings.Repeat("awesome ", 20)
+
+	// When
+	slug := Make(title)
+
+	// Then
+	assert.LessOrEqual(t, len(slug), MaxLength)
+	assert.Equal(t, strings.TrimSuffix(strings.Repeat("awesome-", 12), "-"), slug)
+	assert.Len(t, Make(strings.Repeat("a", 150)), MaxLength, "A single long word must be cut at MaxLength")
+}
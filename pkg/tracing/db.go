@@ -0,0 +1,133 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/logging"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+ * DB and Tx wrap the database/sql ones to start a client span for every query and log it at debug level
+ * Only the statement is recorded, the arguments are never added to the spans nor the logs
+ */
+
+type DB struct {
+	db     *sql.DB
+	system attribute.KeyValue
+}
+
+type Tx struct {
+	tx     *sql.Tx
+	system attribute.KeyValue
+}
+
+// WrapDB traces the queries run through the returned DB, system is the db.system attribute e.g. semconv.DBSystemPostgreSQL
+func WrapDB(db *sql.DB, system attribute.KeyValue) *DB {
+	return &DB{db: db, system: system}
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span, start := startQuery(ctx, db.system, query)
+	rows, err := db.db.QueryContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return rows, err
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span, start := startQuery(ctx, db.system, query)
+	row := db.db.QueryRowContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, row.Err())
+	return row
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span, start := startQuery(ctx, db.system, query)
+	result, err := db.db.ExecContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return result, err
+}
+
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	tx, err := db.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{tx: tx, system: db.system}, nil
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span, start := startQuery(ctx, tx.system, query)
+	rows, err := tx.tx.QueryContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return rows, err
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span, start := startQuery(ctx, tx.system, query)
+	row := tx.tx.QueryRowContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, row.Err())
+	return row
+}
+
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span, start := startQuery(ctx, tx.system, query)
+	result, err := tx.tx.ExecContext(ctx, query, args...)
+	endQuery(ctx, span, start, query, err)
+	return result, err
+}
+
+func (tx *Tx) Commit() error {
+	return tx.tx.Commit()
+}
+
+func (tx *Tx) Rollback() error {
+	return tx.tx.Rollback()
+}
+
+func startQuery(ctx context.Context, system attribute.KeyValue, query string) (context.Context, trace.Span, time.Time) {
+	statement := SanitizeSQL(query)
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+	ctx, span := tracer().Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		system,
+		semconv.DBOperationName(operation),
+		semconv.DBQueryText(statement),
+	))
+	return ctx, span, time.Now()
+}
+
+func endQuery(ctx context.Context, span trace.Span, start time.Time, query string, err error) {
+	End(span, err)
+	logger := logging.For(ctx, "repository")
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attributes := []any{"query", SanitizeSQL(query), "duration_ms", float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		attributes = append(attributes, "error", err)
+	}
+	logger.Debug("Query executed", attributes...)
+}
+
+var (
+	whitespace     = regexp.MustCompile(`\s+`)
+	stringLiterals = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// a number that's not part of an identifier or a $1 placeholder
+	numberLiterals = regexp.MustCompile(`(^|[^\w$.])\d+(?:\.\d+)?`)
+)
+
+// SanitizeSQL collapses the whitespaces of the query and replaces its string and number literals with ?
+// so nothing but the statement's shape ends up in the traces
+func SanitizeSQL(query string) string {
+	query = whitespace.ReplaceAllString(strings.TrimSpace(query), " ")
+	query = stringLiterals.ReplaceAllString(query, "?")
+	return numberLiterals.ReplaceAllString(query, "${1}?")
+}
@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// fileClient appends every batch of spans to a file as an OTLP/JSON export request per line,
+// the format the collector's otlpjsonfile receiver reads so the traces can be imported later
+type fileClient struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+var _ otlptrace.Client = (*fileClient)(nil)
+
+func newFileClient(path string) *fileClient {
+	return &fileClient{path: path}
+}
+
+func (client *fileClient) Start(ctx context.Context) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	file, err := os.OpenFile(client.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	client.file = file
+	return nil
+}
+
+func (client *fileClient) Stop(ctx context.Context) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.file == nil {
+		return nil
+	}
+	err := client.file.Close()
+	client.file = nil
+	return err
+}
+
+func (client *fileClient) UploadTraces(ctx context.Context, spans []*tracepb.ResourceSpans) error {
+	line, err := marshalOtlpJson(&collectortrace.ExportTraceServiceRequest{ResourceSpans: spans})
+	if err != nil {
+		return err
+	}
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if client.file == nil {
+		return os.ErrClosed
+	}
+	_, err = client.file.Write(append(line, '\n'))
+	return err
+}
+
+// marshalOtlpJson marshals the request with the OTLP/JSON encoding which differs from protojson's
+// as trace and span ids are hex encoded rather than base64
+func marshalOtlpJson(request *collectortrace.ExportTraceServiceRequest) ([]byte, error) {
+	encoded, err := protojson.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	var document any
+	if err = json.Unmarshal(encoded, &document); err != nil {
+		return nil, err
+	}
+	if err = hexEncodeIds(document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+func hexEncodeIds(node any) error {
+	switch node := node.(type) {
+	case map[string]any:
+		for key, value := range node {
+			id, isString := value.(string)
+			if isString && (key == "traceId" || key == "spanId" || key == "parentSpanId") {
+				decoded, err := base64.StdEncoding.DecodeString(id)
+				if err != nil {
+					return err
+				}
+				node[key] = hex.EncodeToString(decoded)
+			} else if err := hexEncodeIds(value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, value := range node {
+			if err := hexEncodeIds(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
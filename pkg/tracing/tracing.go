@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName = "go-articles-test"
+
+	ExporterNone     = "none"
+	ExporterStdout   = "stdout"
+	ExporterOtlpFile = "otlp-file"
+
+	DefaultOtlpFile = "traces.jsonl"
+)
+
+const instrumentationName = "github.com/ahmed-e-abdulaziz/go-articles-test"
+
+// Init sets up the global tracer provider with the chosen exporter and the W3C trace context propagator
+// exporter is either none (default), stdout or otlp-file, path is the file the otlp-file exporter appends to
+// the returned function flushes the pending spans and must be called on shutdown
+func Init(ctx context.Context, exporter string, path string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOtlpFile:
+		if path == "" {
+			path = DefaultOtlpFile
+		}
+		spanExporter, err = otlptrace.New(ctx, newFileClient(path))
+	default:
+		return nil, fmt.Errorf("the traces exporter must be either none, stdout or otlp-file, the provided value was [%s]", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span as a child of the one in ctx if any
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err on the span if it's not nil then ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracer is looked up on every span as the global provider is only set by Init, spans before it are no-ops
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	t.Run("Placeholders and identifiers are kept", func(t *testing.T) {
+		query := `SELECT id, title FROM article
+			WHERE (creation_timestamp, id) > ($1, $2) LIMIT $3`
+		assert.Equal(t, "SELECT id, title FROM article WHERE (creation_timestamp, id) > ($1, $2) LIMIT $3", SanitizeSQL(query))
+	})
+	t.Run("Literals are replaced", func(t *testing.T) {
+		query := "SELECT snippet(article_fts, 1, '<mark>', 'it''s', 30), -bm25(article_fts, 10.0, 1.0) FROM article_fts WHERE id = 42"
+		assert.Equal(t, "SELECT snippet(article_fts, ?, ?, ?, ?), -bm25(article_fts, ?, ?) FROM article_fts WHERE id = ?", SanitizeSQL(query))
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	// Given
+	recorder := useRecorder(t)
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	var handlerSpan trace.SpanContext
+	engine.GET("/v1/articles/:id", func(c *gin.Context) {
+		handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+	request := httptest.NewRequest(http.MethodGet, "/v1/articles/1", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// When
+	engine.ServeHTTP(httptest.NewRecorder(), request)
+
+	// Then
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /v1/articles/:id", spans[0].Name(), "The span must be named after the route")
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String(), "The span must continue the incoming trace")
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, spans[0].SpanContext(), handlerSpan, "The span must be passed to the handler through the request's context")
+	assert.Contains(t, spans[0].Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+}
+
+func TestInitWithOtlpFile(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Init(context.Background(), ExporterOtlpFile, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	// When
+	_, span := Start(context.Background(), "test span")
+	traceId := span.SpanContext().TraceID().String()
+	End(span, nil)
+	require.NoError(t, shutdown(context.Background()))
+
+	// Then
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	require.True(t, scanner.Scan(), "The spans must be flushed to the file on shutdown")
+	assert.Contains(t, scanner.Text(), `"resourceSpans"`)
+	assert.Contains(t, scanner.Text(), `"name":"test span"`)
+	assert.Contains(t, scanner.Text(), `"traceId":"`+traceId+`"`, "The ids must be hex encoded as OTLP/JSON requires")
+}
+
+func TestInitWithUnknownExporter(t *testing.T) {
+	_, err := Init(context.Background(), "zipkin", "")
+	assert.Error(t, err)
+}
+
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return recorder
+}
@@ -1,10 +0,0 @@
-package utils
-
-func ContainsEmpty(ss ...string) bool {
-	for _, s := range ss {
-		if s == "" {
-			return true
-		}
-	}
-	return false
-}
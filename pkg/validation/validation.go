@@ -0,0 +1,100 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+	"github.com/go-playground/validator/v10"
+)
+
+/*
+ * Validation rules are declared on the models using the validate struct tag, e.g. `validate:"trim,required,max=255,singleline"`
+ * On top of the validator's built-in rules the following are supported:
+ * - trim: trims the whitespace around the value before it's validated
+ * - singleline: no control characters are allowed
+ * - multiline: no control characters are allowed except for new lines and tabs
+ * - tag: only letters, digits and the - _ . + # characters are allowed
+ */
+
+var ErrValidationFailed = domainerr.Validation("validation_failed", "the request has invalid fields")
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			return field.Name
+		}
+		return name
+	})
+	v.RegisterValidation("trim", func(validator.FieldLevel) bool { return true }) // applied by Validate itself
+	v.RegisterValidation("singleline", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsFunc(fl.Field().String(), unicode.IsControl)
+	})
+	v.RegisterValidation("multiline", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsFunc(fl.Field().String(), func(r rune) bool {
+			return unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t'
+		})
+	})
+	v.RegisterValidation("tag", func(fl validator.FieldLevel) bool {
+		return !strings.ContainsFunc(fl.Field().String(), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.+#", r)
+		})
+	})
+	return v
+}
+
+// Validate trims the fields tagged with trim then checks all the rules of the struct pointed to by model
+// returns ErrValidationFailed with all the invalid fields if any rule isn't satisfied
+func Validate(model any) error {
+	trim(reflect.ValueOf(model).Elem())
+	err := validate.Struct(model)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	fields := make([]domainerr.FieldError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		fields = append(fields, toFieldError(fieldErr))
+	}
+	return ErrValidationFailed.WithFields(fields)
+}
+
+func trim(value reflect.Value) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		if field.Kind() != reflect.String || !field.CanSet() {
+			continue
+		}
+		rules := strings.Split(value.Type().Field(i).Tag.Get("validate"), ",")
+		for _, rule := range rules {
+			if rule == "trim" {
+				field.SetString(strings.TrimSpace(field.String()))
+			}
+		}
+	}
+}
+
+func toFieldError(err validator.FieldError) domainerr.FieldError {
+	switch err.Tag() {
+	case "required":
+		return domainerr.FieldError{Field: err.Field(), Code: "required", Message: "is required"}
+	case "max":
+		if err.Kind() == reflect.Slice {
+			return domainerr.FieldError{Field: err.Field(), Code: "too_many", Message: "must have at most " + err.Param() + " items"}
+		}
+		return domainerr.FieldError{Field: err.Field(), Code: "too_long", Message: "must be at most " + err.Param() + " characters"}
+	case "singleline":
+		return domainerr.FieldError{Field: err.Field(), Code: "control_characters", Message: "must not contain control characters"}
+	case "multiline":
+		return domainerr.FieldError{Field: err.Field(), Code: "control_characters", Message: "must not contain control characters other than new lines and tabs"}
+	case "tag":
+		return domainerr.FieldError{Field: err.Field(), Code: "invalid_tag", Message: "must only contain letters, digits and the - _ . + # characters"}
+	}
+	return domainerr.FieldError{Field: err.Field(), Code: err.Tag(), Message: "must satisfy " + err.Tag()}
+}
@@ -0,0 +1,68 @@
+package validation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/domainerr"
+	"github.com/ahmed-e-abdulaziz/go-articles-test/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateShouldTrimFields(t *testing.T) {
+	// Given
+	article := &models.Article{Title: "  Awesome Go \t", Content: "\nA curated list\nof awesome Go frameworks  "}
+
+	// When
+	err := Validate(article)
+
+	// Then
+	assert.NoError(t, err)
+	assert.Equal(t, "Awesome Go", article.Title)
+	assert.Equal(t, "A curated list\nof awesome Go frameworks", article.Content)
+}
+
+func TestValidateShouldReportInvalidFields(t *testing.T) {
+	t.Run("Blank fields", func(t *testing.T) {
+		err := Validate(&models.Comment{Author: "   ", Content: ""})
+		assert.Equal(t, []domainerr.FieldError{
+			{Field: "author", Code: "required", Message: "is required"},
+			{Field: "content", Code: "required", Message: "is required"},
+		}, fieldErrors(t, err))
+	})
+	t.Run("Too long title", func(t *testing.T) {
+		err := Validate(&models.Article{Title: strings.Repeat("é", 256), Content: "Content"})
+		assert.Equal(t, []domainerr.FieldError{
+			{Field: "title", Code: "too_long", Message: "must be at most 255 characters"},
+		}, fieldErrors(t, err))
+	})
+	t.Run("Control characters", func(t *testing.T) {
+		err := Validate(&models.Article{Title: "Awesome\nGo", Content: "Awesome\x00Go"})
+		assert.Equal(t, []domainerr.FieldError{
+			{Field: "title", Code: "control_characters", Message: "must not contain control characters"},
+			{Field: "content", Code: "control_characters", Message: "must not contain control characters other than new lines and tabs"},
+		}, fieldErrors(t, err))
+	})
+	t.Run("Invalid tags", func(t *testing.T) {
+		err := Validate(&models.Article{Title: "Awesome Go", Content: "Content", Tags: []string{"go", "", "awesome go", strings.Repeat("a", 51)}})
+		assert.Equal(t, []domainerr.FieldError{
+			{Field: "tags[1]", Code: "required", Message: "is required"},
+			{Field: "tags[2]", Code: "invalid_tag", Message: "must only contain letters, digits and the - _ . + # characters"},
+			{Field: "tags[3]", Code: "too_long", Message: "must be at most 50 characters"},
+		}, fieldErrors(t, err))
+	})
+	t.Run("Too many tags", func(t *testing.T) {
+		err := Validate(&models.Article{Title: "Awesome Go", Content: "Content", Tags: make([]string, 21)})
+		assert.Equal(t, []domainerr.FieldError{
+			{Field: "tags", Code: "too_many", Message: "must have at most 20 items"},
+		}, fieldErrors(t, err))
+	})
+}
+
+func fieldErrors(t *testing.T, err error) []domainerr.FieldError {
+	var domainErr *domainerr.Error
+	assert.True(t, errors.As(err, &domainErr))
+	assert.ErrorIs(t, err, ErrValidationFailed)
+	return domainErr.Fields
+}